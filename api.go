@@ -0,0 +1,374 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// DefaultMaxBodySize is the default maximum size of the body of a request to the APIServer
+const DefaultMaxBodySize = 16 << 20
+
+// DefaultMaxMatchRPIs is the default maximum number of RPIs of a match request to the APIServer,
+// more than the RPIs seen by a phone in the 14 days of retention of the keys
+const DefaultMaxMatchRPIs = 100000
+
+// DefaultMaxQueryKeys is the default maximum number of keys of a decode request with a query
+// and the json format, whose rows are collected in memory to apply the query
+const DefaultMaxQueryKeys = 10000
+
+// APIServer exposes the decode, verify and match operations over HTTP:
+//
+//	POST /v1/decode   body: export zip or export.bin, params: query, rows, format (json or ndjson)
+//	POST /v1/verify   body: export zip, verified against the PublicKeys
+//	POST /v1/match    body: {"rpis": [...], "from": "2006-01-02", "to": "2006-01-02"}
+//
+// The errors are returned as {"error": "message"}.
+type APIServer struct {
+	// Exports are the export files or folders the RPIs are matched against
+	Exports []string
+	// Index, if set, is used to match the RPIs instead of scanning the Exports
+	Index *RPIIndex
+	// PublicKeys are the keys used to verify the signatures
+	PublicKeys []*ecdsa.PublicKey
+	// MaxBodySize is the maximum size of the body of a request, in bytes
+	MaxBodySize int64
+	// MaxMatchRPIs is the maximum number of RPIs of a match request
+	MaxMatchRPIs int
+	// MaxQueryKeys is the maximum number of keys of a decode request with a query and the json format
+	MaxQueryKeys int
+	// IDFormat is the format of the IDs in the requests and in the responses, base64 if empty
+	IDFormat IDFormat
+}
+
+// apiError is an error with the HTTP status code of the response
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+// badRequest returns an apiError with the 400 status code
+func badRequest(format string, args ...interface{}) error {
+	return &apiError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
+
+// matchRequest is the body of a match request
+type matchRequest struct {
+	RPIs []string `json:"rpis"`
+	From string   `json:"from"`
+	To   string   `json:"to"`
+}
+
+// ServeHTTP serves the API
+func (s *APIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handler func(w http.ResponseWriter, r *http.Request) error
+	switch r.URL.Path {
+	case "/v1/decode":
+		handler = s.decode
+	case "/v1/verify":
+		handler = s.verify
+	case "/v1/match":
+		handler = s.match
+	default:
+		writeAPIError(w, &apiError{status: http.StatusNotFound, err: errors.New("not found")})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeAPIError(w, &apiError{status: http.StatusMethodNotAllowed, err: errors.New("method not allowed")})
+		return
+	}
+
+	if err := handler(w, r); err != nil {
+		writeAPIError(w, err)
+	}
+}
+
+// decode decodes the uploaded export, with the same output options of the decode command.
+// The keys are streamed to the response, after the options and the keys are checked.
+func (s *APIServer) decode(w http.ResponseWriter, r *http.Request) error {
+	bin, _, err := s.readUpload(r)
+	if err != nil {
+		return err
+	}
+	exp, err := UnmarshalExport(bin)
+	if err != nil {
+		return badRequest("%s", err)
+	}
+
+	params := r.URL.Query()
+	opts := OutputOptions{Format: OutputJSON, Rows: RowsTEK, Query: params.Get("query"), IDFormat: s.IDFormat}
+	if format := params.Get("format"); format != "" {
+		opts.Format = format
+	}
+	if rows := params.Get("rows"); rows != "" {
+		opts.Rows = rows
+	}
+	if opts.Format != OutputJSON && opts.Format != OutputNDJSON {
+		return badRequest("unknown format [%s]: use %s or %s", opts.Format, OutputJSON, OutputNDJSON)
+	}
+
+	// everything that can fail is checked before the first byte is written, so it is still returned as a JSON error
+	if _, err := NewKeyWriter(ioutil.Discard, opts); err != nil {
+		return badRequest("%s", err)
+	}
+	if opts.Query != "" {
+		if _, err := jmespath.Compile(opts.Query); err != nil {
+			return badRequest("invalid query: %s", err)
+		}
+	}
+	for _, key := range exp.Keys {
+		if len(key.GetKeyData()) != 16 {
+			return badRequest("invalid key of %d bytes", len(key.GetKeyData()))
+		}
+	}
+	if max := s.maxQueryKeys(); opts.Query != "" && opts.Format == OutputJSON && len(exp.Keys) > max {
+		return &apiError{
+			status: http.StatusRequestEntityTooLarge,
+			err:    fmt.Errorf("%d keys, more than the limit of %d of a query with the %s format: use %s", len(exp.Keys), max, OutputJSON, OutputNDJSON),
+		}
+	}
+
+	contentType := "application/json"
+	if opts.Format == OutputNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	kw, err := NewKeyWriter(w, opts)
+	if err != nil {
+		return badRequest("%s", err)
+	}
+
+	rpis := RPIsNone
+	if opts.NeedsRPIs() {
+		rpis = RPIsEager
+	}
+	it, err := NewKeyIterator(exp, rpis)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		if err := kw.Write(it.Key()); err != nil {
+			abortResponse(err)
+		}
+	}
+	if err := it.Err(); err != nil {
+		abortResponse(err)
+	}
+	if err := kw.Close(); err != nil {
+		abortResponse(err)
+	}
+	return nil
+}
+
+// abortResponse aborts a response already partly written, that can no longer carry a JSON error,
+// so the client sees a truncated response instead of a valid one
+func abortResponse(err error) {
+	fmt.Fprintf(os.Stderr, "aborting the response: %s\n", err)
+	panic(http.ErrAbortHandler)
+}
+
+// maxQueryKeys returns the MaxQueryKeys, or the DefaultMaxQueryKeys if not set
+func (s *APIServer) maxQueryKeys() int {
+	if s.MaxQueryKeys <= 0 {
+		return DefaultMaxQueryKeys
+	}
+	return s.MaxQueryKeys
+}
+
+// verify verifies the signatures of the uploaded export zip
+func (s *APIServer) verify(w http.ResponseWriter, r *http.Request) error {
+	bin, sig, err := s.readUpload(r)
+	if err != nil {
+		return err
+	}
+
+	valid, checks, err := s.verifyExport(bin, sig)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, map[string]interface{}{"valid": valid, "signatures": checks})
+}
+
+// verifyExport verifies the signatures of the export with the public keys, returning true if all are valid
+func (s *APIServer) verifyExport(bin, sig []byte) (bool, []SignatureCheck, error) {
+	if len(s.PublicKeys) == 0 {
+		return false, nil, &apiError{status: http.StatusNotImplemented, err: errors.New("no public keys configured")}
+	}
+	if sig == nil {
+		return false, nil, badRequest("%s not found: upload the export zip", ExportSigFilename)
+	}
+
+	checks, err := VerifyExport(bin, sig, s.PublicKeys)
+	if err != nil {
+		return false, nil, badRequest("%s", err)
+	}
+
+	valid := true
+	for _, c := range checks {
+		valid = valid && c.Valid
+	}
+	return valid, checks, nil
+}
+
+// match matches the posted RPIs against the index, or the exports
+func (s *APIServer) match(w http.ResponseWriter, r *http.Request) error {
+	body, err := s.readBody(r)
+	if err != nil {
+		return err
+	}
+	var req matchRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return badRequest("invalid match request: %s", err)
+	}
+	if len(req.RPIs) == 0 {
+		return badRequest("no rpis")
+	}
+	if err := s.checkMatchRPIs(len(req.RPIs)); err != nil {
+		return err
+	}
+
+	rpis := make([]ID, 0, len(req.RPIs))
+	for _, id := range req.RPIs {
+		rpi, err := s.IDFormat.Parse(id)
+		if err != nil {
+			return badRequest("%s", err)
+		}
+		rpis = append(rpis, rpi)
+	}
+
+	window, err := parseWindow(req.From, req.To)
+	if err != nil {
+		return badRequest("%s", err)
+	}
+
+	matches, err := s.matchRPIs(rpis, window)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, FormatMatches(matches, s.IDFormat))
+}
+
+// checkMatchRPIs checks the number of RPIs of a match request against the MaxMatchRPIs
+func (s *APIServer) checkMatchRPIs(n int) error {
+	max := s.MaxMatchRPIs
+	if max <= 0 {
+		max = DefaultMaxMatchRPIs
+	}
+	if n > max {
+		return badRequest("%d rpis, more than the limit of %d", n, max)
+	}
+	return nil
+}
+
+// matchRPIs matches the RPIs against the index, or the exports in the window
+func (s *APIServer) matchRPIs(rpis []ID, window Window) ([]*RPIMatch, error) {
+	if err := s.checkMatchRPIs(len(rpis)); err != nil {
+		return nil, err
+	}
+
+	matches := make([]*RPIMatch, 0)
+	if s.Index != nil {
+		for _, rpi := range rpis {
+			match, err := s.Index.Lookup(rpi)
+			if err != nil {
+				return nil, err
+			}
+			if match != nil {
+				matches = append(matches, match)
+			}
+		}
+		return matches, nil
+	}
+
+	files, err := ExportFiles(s.Exports)
+	if err != nil {
+		return nil, err
+	}
+	found, err := ScanRPIs(files, rpis, window)
+	if err != nil {
+		return nil, err
+	}
+	return append(matches, found...), nil
+}
+
+// maxBodySize returns the MaxBodySize, or the DefaultMaxBodySize if not set
+func (s *APIServer) maxBodySize() int64 {
+	if s.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return s.MaxBodySize
+}
+
+// readBody reads the body of the request, up to the maxBodySize
+func (s *APIServer) readBody(r *http.Request) ([]byte, error) {
+	max := s.maxBodySize()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, max+1))
+	if int64(len(body)) > max {
+		return nil, &apiError{status: http.StatusRequestEntityTooLarge, err: fmt.Errorf("request body larger than %d bytes", max)}
+	}
+	if err != nil {
+		return nil, badRequest("%s", err)
+	}
+	return body, nil
+}
+
+// readUpload reads the export.bin and export.sig of an uploaded export zip, or an uploaded export.bin
+func (s *APIServer) readUpload(r *http.Request) ([]byte, []byte, error) {
+	body, err := s.readBody(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return readExportData(body)
+}
+
+// readExportData reads the export.bin and export.sig of the data of an export zip, or of an export.bin
+func readExportData(data []byte) ([]byte, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, badRequest("no export: upload an export zip or export.bin")
+	}
+
+	if !IsZip(data) {
+		return data, nil, nil
+	}
+	bin, sig, err := ReadExportZip(data, DefaultUnzipLimits)
+	if err != nil {
+		return nil, nil, badRequest("%s", err)
+	}
+	return bin, sig, nil
+}
+
+// writeJSON writes the value as the JSON body of the response
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	return err
+}
+
+// writeAPIError writes the error as the JSON body of the response
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		status = apiErr.status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ArchiveIndexFilename is the name of the index file of an Archive
+const ArchiveIndexFilename = "index.jsonl"
+
+// Archive is a content-addressed store of the original export zips.
+// The zips are stored once by their SHA-256 under dir/blobs, and every download
+// is recorded in the dir/index.jsonl file, one JSON ArchiveEntry per line.
+type Archive struct {
+	Dir string
+}
+
+// ArchiveEntry records an export zip fetched from a server
+type ArchiveEntry struct {
+	App       string    `json:"app"`
+	Export    string    `json:"export"`
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+}
+
+// NewArchive returns the Archive in the dir folder, creating it if needed
+func NewArchive(dir string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "blobs"), os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &Archive{Dir: dir}, nil
+}
+
+// BlobPath returns the path of the blob with the specified SHA-256
+func (a *Archive) BlobPath(sum string) string {
+	if len(sum) < 2 {
+		return filepath.Join(a.Dir, "blobs", sum)
+	}
+	return filepath.Join(a.Dir, "blobs", sum[:2], sum+".zip")
+}
+
+// Put stores a copy of the file in the archive, returning its SHA-256 and size.
+// If a blob with the same content is already stored it is not written again.
+func (a *Archive) Put(filename string) (string, int64, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return "", 0, err
+	}
+	defer in.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Join(a.Dir, "blobs"), tmpDirPrefix)
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), in)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	blobPath := a.BlobPath(sum)
+	if _, err := os.Stat(blobPath); err == nil {
+		return sum, size, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(blobPath), os.ModePerm); err != nil {
+		return "", 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0444); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), blobPath); err != nil {
+		return "", 0, err
+	}
+	return sum, size, nil
+}
+
+// Record appends the entry to the archive index
+func (a *Archive) Record(entry ArchiveEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(a.Dir, ArchiveIndexFilename), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Entries returns the entries of the archive index, sorted by fetch time
+func (a *Archive) Entries() ([]ArchiveEntry, error) {
+	entries := make([]ArchiveEntry, 0)
+
+	f, err := os.Open(filepath.Join(a.Dir, ArchiveIndexFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry ArchiveEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", ArchiveIndexFilename, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].FetchedAt.Before(entries[j].FetchedAt)
+	})
+	return entries, nil
+}
+
+// PublishedAt returns, for every app and export, the latest entry fetched at or before t
+func (a *Archive) PublishedAt(t time.Time) ([]ArchiveEntry, error) {
+	entries, err := a.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]int)
+	keys := make([]string, 0)
+	for i, entry := range entries {
+		if entry.FetchedAt.After(t) {
+			break
+		}
+		key := entry.App + "/" + entry.Export
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = i
+	}
+
+	published := make([]ArchiveEntry, 0, len(keys))
+	for _, key := range keys {
+		published = append(published, entries[latest[key]])
+	}
+	return published, nil
+}
+
+// Verify checks that every blob referenced by the index exists and matches its SHA-256
+func (a *Archive) Verify() ([]FsckResult, error) {
+	entries, err := a.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]FsckResult, 0)
+	seen := make(map[string]bool)
+
+	for _, entry := range entries {
+		if seen[entry.SHA256] {
+			continue
+		}
+		seen[entry.SHA256] = true
+
+		blobPath := a.BlobPath(entry.SHA256)
+		result := FsckResult{Path: blobPath, Problems: make([]string, 0)}
+
+		sum, err := sha256File(blobPath)
+		if err != nil {
+			result.Problems = append(result.Problems, err.Error())
+		} else if sum != entry.SHA256 {
+			result.Problems = append(result.Problems, "checksum mismatch")
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// parseTime parses a time in the RFC3339 format, or a date in the 2006-01-02 format (UTC)
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s': use the RFC3339 or 2006-01-02 format", s)
+	}
+	return t, nil
+}
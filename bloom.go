@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"gaen/export"
+	"io"
+	"math"
+	"os"
+)
+
+// bloomMagic is the header of a serialized BloomFilter
+var bloomMagic = [8]byte{'G', 'A', 'E', 'N', 'B', 'F', '1', 0}
+
+// bloomHeaderSize is the size of the header of a serialized BloomFilter, in bytes
+const bloomHeaderSize = 8 + 8 + 8 + 8 + 4 + 8
+
+// MaxBloomFilterBits is the maximum number of bits of a BloomFilter read with ReadBloomFilter (1 GiB),
+// enough for billions of RPIs with a false positive rate of 0.01
+const MaxBloomFilterBits = 8 << 30
+
+// BloomFilter is a compact probabilistic set of RPIs, with a configurable false positive rate.
+// A Test can return a false positive, but never a false negative.
+//
+// The RPIs are the output of AES, so they are already uniformly distributed and
+// the two halves of an RPI are used directly as the hashes for the double hashing.
+type BloomFilter struct {
+	// Window is the range of intervals of the RPIs added to the filter
+	Window Window
+	// N is the number of RPIs added to the filter
+	N uint64
+	// K is the number of hash functions
+	K uint32
+	// M is the number of bits
+	M    uint64
+	bits []uint64
+}
+
+// NewBloomFilter returns a BloomFilter sized for n RPIs with the specified false positive rate
+func NewBloomFilter(n int, fpRate float64) (*BloomFilter, error) {
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, fmt.Errorf("invalid false positive rate %g: it must be between 0 and 1", fpRate)
+	}
+	if n < 1 {
+		n = 1
+	}
+
+	m := math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+
+	words := (uint64(m) + 63) / 64
+	return &BloomFilter{
+		K:    uint32(k),
+		M:    words * 64,
+		bits: make([]uint64, words),
+	}, nil
+}
+
+// Add adds the RPI to the filter
+func (f *BloomFilter) Add(rpi ID) {
+	h1, h2 := bloomHashes(rpi)
+	for i := uint32(0); i < f.K; i++ {
+		bit := (h1 + uint64(i)*h2) % f.M
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.N++
+}
+
+// Test returns true if the RPI may be in the filter, and false if it is surely not
+func (f *BloomFilter) Test(rpi ID) bool {
+	h1, h2 := bloomHashes(rpi)
+	for i := uint32(0); i < f.K; i++ {
+		bit := (h1 + uint64(i)*h2) % f.M
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// FalsePositiveRate returns the expected false positive rate with the RPIs added so far
+func (f *BloomFilter) FalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.K)*float64(f.N)/float64(f.M)), float64(f.K))
+}
+
+// WriteTo serializes the filter to the writer
+func (f *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+
+	header := []interface{}{
+		bloomMagic,
+		int64(f.Window.From),
+		int64(f.Window.To),
+		f.N,
+		f.K,
+		f.M,
+	}
+	for _, v := range header {
+		if err := binary.Write(bw, binary.LittleEndian, v); err != nil {
+			return 0, err
+		}
+	}
+	if err := binary.Write(bw, binary.LittleEndian, f.bits); err != nil {
+		return 0, err
+	}
+
+	size := int64(bloomHeaderSize + 8*len(f.bits))
+	return size, bw.Flush()
+}
+
+// ReadBloomFilter deserializes a filter written with WriteTo. The size of the filter is checked against
+// the size of the serialized data, if known (not negative), and the MaxBloomFilterBits before reading the bits.
+func ReadBloomFilter(r io.Reader, size int64) (*BloomFilter, error) {
+	br := bufio.NewReader(r)
+
+	var magic [8]byte
+	if err := binary.Read(br, binary.LittleEndian, &magic); err != nil {
+		return nil, err
+	}
+	if magic != bloomMagic {
+		return nil, errors.New("not a gaen filter")
+	}
+
+	var from, to int64
+	f := &BloomFilter{}
+	for _, v := range []interface{}{&from, &to, &f.N, &f.K, &f.M} {
+		if err := binary.Read(br, binary.LittleEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	if f.K == 0 || f.M == 0 || f.M%64 != 0 {
+		return nil, errors.New("invalid gaen filter")
+	}
+	if f.M > MaxBloomFilterBits {
+		return nil, fmt.Errorf("invalid gaen filter: %d bits, more than the limit of %d", f.M, uint64(MaxBloomFilterBits))
+	}
+	if size >= 0 && uint64(size-bloomHeaderSize) != f.M/8 {
+		return nil, fmt.Errorf("invalid gaen filter: %d bits in %d bytes", f.M, size)
+	}
+	f.Window = Window{From: int(from), To: int(to)}
+
+	f.bits = make([]uint64, f.M/64)
+	if err := binary.Read(br, binary.LittleEndian, f.bits); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ReadBloomFilterFile deserializes the filter in the file
+func ReadBloomFilterFile(filename string) (*BloomFilter, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return ReadBloomFilter(in, info.Size())
+}
+
+// BuildRPIFilter returns a BloomFilter of all the RPIs in the window derived from the export files
+func BuildRPIFilter(files []ExportFile, window Window, fpRate float64) (*BloomFilter, error) {
+	// the RPIs are counted upfront to size the filter, and the exports are kept to derive them
+	exports := make([]*export.TemporaryExposureKeyExport, 0, len(files))
+	n := 0
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		exports = append(exports, exp)
+
+		for _, key := range exp.Keys {
+			for i := int(key.GetRollingStartIntervalNumber()); i < int(key.GetRollingStartIntervalNumber()+key.GetRollingPeriod()); i++ {
+				if window.Contains(i) {
+					n++
+				}
+			}
+		}
+	}
+
+	filter, err := NewBloomFilter(n, fpRate)
+	if err != nil {
+		return nil, err
+	}
+	filter.Window = window
+
+	add := func(file ExportFile, exp *export.TemporaryExposureKeyExport, tek *TemporaryExposureKey, rpi *RollingProximityIdentifier) error {
+		filter.Add(rpi.ID)
+		return nil
+	}
+	for i, file := range files {
+		if err := forEachExportRPI(file, exports[i], window, add); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// MatchRPIs checks the candidate RPIs against the filter, and confirms the ones that may be in it
+// deriving the RPIs of the export files. Only the confirmed matches are returned.
+func MatchRPIs(filter *BloomFilter, files []ExportFile, candidates []ID) ([]*RPIMatch, error) {
+	maybe := make([]ID, 0)
+	for _, rpi := range candidates {
+		if filter.Test(rpi) {
+			maybe = append(maybe, rpi)
+		}
+	}
+	if len(maybe) == 0 {
+		return make([]*RPIMatch, 0), nil
+	}
+	return ScanRPIs(files, maybe, filter.Window)
+}
+
+// bloomHashes returns the two hashes of the RPI used for the double hashing
+func bloomHashes(rpi ID) (uint64, uint64) {
+	var b [16]byte
+	copy(b[:], rpi)
+	h1 := binary.LittleEndian.Uint64(b[:8])
+	h2 := binary.LittleEndian.Uint64(b[8:]) | 1
+	return h1, h2
+}
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/aes"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"gaen/export"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/hkdf"
@@ -26,13 +34,21 @@ func DecodeFromFile(filename string) ([]*TemporaryExposureKey, error) {
 	return DecodeExport(export)
 }
 
-// UnmarshalExportFile unmarshal a TemporaryExposureKeyExport binary file
+// UnmarshalExportFile unmarshal a TemporaryExposureKeyExport binary file, or the one in an export zip
 func UnmarshalExportFile(filename string) (*export.TemporaryExposureKeyExport, error) {
-	in, err := ioutil.ReadFile(filename)
+	in, err := ReadExportBin(filename)
 	if err != nil {
 		return nil, err
 	}
-	in = in[16:]
+	return UnmarshalExport(in)
+}
+
+// UnmarshalExport unmarshal the content of a TemporaryExposureKeyExport binary file
+func UnmarshalExport(in []byte) (*export.TemporaryExposureKeyExport, error) {
+	if len(in) < len(ExportHeader) {
+		return nil, errors.New("export too short")
+	}
+	in = in[len(ExportHeader):]
 
 	export := &export.TemporaryExposureKeyExport{}
 	if err := proto.Unmarshal(in, export); err != nil {
@@ -41,44 +57,184 @@ func UnmarshalExportFile(filename string) (*export.TemporaryExposureKeyExport, e
 	return export, nil
 }
 
+// ReadExportBin reads an export.bin file, without its export.sig file.
+// If the file is an export zip, the export.bin in the zip is read.
+func ReadExportBin(filename string) ([]byte, error) {
+	in, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsZip(in) {
+		bin, _, err := ReadExportZip(in, DefaultUnzipLimits)
+		return bin, err
+	}
+	return in, nil
+}
+
+// ReadExportFile reads an export.bin file and its export.sig file, if it exists in the same folder.
+// If the file is an export zip, the files in the zip are read.
+func ReadExportFile(filename string) ([]byte, []byte, error) {
+	in, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if IsZip(in) {
+		return ReadExportZip(in, DefaultUnzipLimits)
+	}
+
+	sig, err := ioutil.ReadFile(filepath.Join(filepath.Dir(filename), ExportSigFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	return in, sig, nil
+}
+
 // DecodeExport decodes a TemporaryExposureKeyExport to a []*TemporaryExposureKey
 func DecodeExport(export *export.TemporaryExposureKeyExport) ([]*TemporaryExposureKey, error) {
 	teks := make([]*TemporaryExposureKey, 0)
 
-	for _, tek := range export.Keys {
+	it, err := NewKeyIterator(export, RPIsEager)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		teks = append(teks, it.Key())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return teks, nil
+}
+
+// Modes of derivation of the Rolling Proximity Identifiers
+const (
+	// RPIsEager derives the RPIs while decoding the key
+	RPIsEager = "eager"
+	// RPIsLazy derives the RPIs only when LoadRPIs is called
+	RPIsLazy = "lazy"
+	// RPIsNone never derives the RPIs
+	RPIsNone = "none"
+)
+
+// keyBatchSize is the number of keys whose RPIs are derived in parallel by the KeyIterator
+const keyBatchSize = 256
+
+// KeyIterator decodes the keys of a TemporaryExposureKeyExport one at a time,
+// so only the RPIs of the current keys are held in memory.
+// With the RPIsEager mode the RPIs are derived in parallel, in batches of keys.
+//
+//	it, err := NewKeyIterator(export, RPIsLazy)
+//	for it.Next() {
+//		tek := it.Key()
+//	}
+//	err = it.Err()
+type KeyIterator struct {
+	keys  []*export.TemporaryExposureKey
+	rpis  string
+	pos   int
+	batch []*TemporaryExposureKey
+	tek   *TemporaryExposureKey
+	err   error
+}
+
+// NewKeyIterator returns a KeyIterator over the keys of the export, deriving the RPIs with the specified mode
+func NewKeyIterator(export *export.TemporaryExposureKeyExport, rpis string) (*KeyIterator, error) {
+	switch rpis {
+	case RPIsEager, RPIsLazy, RPIsNone:
+	default:
+		return nil, fmt.Errorf("unknown rpis mode [%s]: use %s, %s or %s", rpis, RPIsEager, RPIsLazy, RPIsNone)
+	}
+	return &KeyIterator{keys: export.Keys, rpis: rpis}, nil
+}
+
+// Next decodes the next key, returning false when there are no more keys or an error occurred
+func (it *KeyIterator) Next() bool {
+	it.tek = nil
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.batch) == 0 {
+		if it.pos >= len(it.keys) {
+			return false
+		}
+		if it.err = it.nextBatch(); it.err != nil {
+			it.batch = nil
+			return false
+		}
+	}
+
+	it.tek = it.batch[0]
+	it.batch[0] = nil
+	it.batch = it.batch[1:]
+	return true
+}
+
+// nextBatch decodes the next batch of keys
+func (it *KeyIterator) nextBatch() error {
+	size := 1
+	if it.rpis == RPIsEager {
+		size = keyBatchSize
+	}
+	if left := len(it.keys) - it.pos; left < size {
+		size = left
+	}
+
+	batch := make([]*TemporaryExposureKey, 0, size)
+	for _, tek := range it.keys[it.pos : it.pos+size] {
 		if tek.RollingStartIntervalNumber == nil {
-			return nil, errors.New("cannot decode export: RollingStartIntervalNumber is nil")
+			return errors.New("cannot decode export: RollingStartIntervalNumber is nil")
 		}
 		if tek.RollingPeriod == nil {
-			return nil, errors.New("cannot decode export: RollingPeriod is nil")
+			return errors.New("cannot decode export: RollingPeriod is nil")
 		}
 
-		tek := NewTemporaryExposureKey(
+		batch = append(batch, NewTemporaryExposureKey(
 			tek.KeyData,
 			int(*tek.RollingStartIntervalNumber),
 			int(*tek.RollingPeriod),
-		)
+		))
+	}
+	it.pos += size
 
-		err := DecodeTEK(tek)
-		if err != nil {
-			return nil, err
+	switch it.rpis {
+	case RPIsEager:
+		if err := DecodeTEKs(batch); err != nil {
+			return err
+		}
+	case RPIsLazy:
+		for _, tek := range batch {
+			tek.lazyRPIs = true
 		}
-		teks = append(teks, tek)
 	}
 
-	return teks, nil
+	it.batch = batch
+	return nil
+}
+
+// Key returns the key decoded by the last call to Next
+func (it *KeyIterator) Key() *TemporaryExposureKey {
+	return it.tek
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *KeyIterator) Err() error {
+	return it.err
 }
 
 // DecodeTEK decodes a TemporaryExposureKey calculating its Rolling Proximity Identifiers
 func DecodeTEK(tek *TemporaryExposureKey) error {
 	hkdfReader := hkdf.New(sha256.New, tek.ID, nil, []byte("EN-RPIK"))
 
-	rpiKey := make([]byte, 16)
-	if _, err := io.ReadFull(hkdfReader, rpiKey); err != nil {
+	var rpiKey [16]byte
+	if _, err := io.ReadFull(hkdfReader, rpiKey[:]); err != nil {
 		return err
 	}
 
-	rpis, err := NewRollingProximityIdentifiers(rpiKey, tek.rollingStartInterval, tek.rollingPeriod)
+	rpis, err := NewRollingProximityIdentifiers(rpiKey[:], tek.RollingStartIntervalNumber, tek.RollingPeriod)
 	if err != nil {
 		return err
 	}
@@ -87,17 +243,77 @@ func DecodeTEK(tek *TemporaryExposureKey) error {
 	return nil
 }
 
-// NewRollingProximityIdentifiers returns the Rolling Proximity Identifiers from a key, from the specified starting interval and interval
+// DecodeTEKs decodes the TemporaryExposureKeys in parallel, fanning them out across the CPUs
+func DecodeTEKs(teks []*TemporaryExposureKey) error {
+	workers := runtime.NumCPU()
+	if workers > len(teks) {
+		workers = len(teks)
+	}
+	if workers <= 1 {
+		for _, tek := range teks {
+			if err := DecodeTEK(tek); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	next := make(chan *TemporaryExposureKey)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tek := range next {
+				if err := DecodeTEK(tek); err != nil {
+					once.Do(func() { firstErr = err })
+				}
+			}
+		}()
+	}
+
+	for _, tek := range teks {
+		next <- tek
+	}
+	close(next)
+	wg.Wait()
+
+	return firstErr
+}
+
+// NewRollingProximityIdentifiers returns the Rolling Proximity Identifiers from a key, from the specified starting interval and interval.
+// The cipher is created once for all the intervals, and the identifiers are allocated in a single block.
 func NewRollingProximityIdentifiers(rpiKey []byte, rollingStartInterval, rollingPeriod int) ([]*RollingProximityIdentifier, error) {
-	rpis := make([]*RollingProximityIdentifier, 0)
+	if rollingPeriod < 0 {
+		return nil, fmt.Errorf("invalid rolling period %d", rollingPeriod)
+	}
+
+	cipher, err := aes.NewCipher(rpiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]byte, 16*rollingPeriod)
+	block := make([]RollingProximityIdentifier, rollingPeriod)
+	rpis := make([]*RollingProximityIdentifier, rollingPeriod)
+
+	// the padded block escapes to the heap through the cipher, so it is allocated only once
+	pad := make([]byte, 16)
 
 	for rp := 0; rp < rollingPeriod; rp++ {
 		interval := rp + rollingStartInterval
-		newRpi, err := NewRollingProximityIdentifier(rpiKey, interval)
-		if err != nil {
-			return nil, err
-		}
-		rpis = append(rpis, newRpi)
+		rpi := &block[rp]
+		rpi.ID = ids[rp*16 : (rp+1)*16 : (rp+1)*16]
+		rpi.IntervalNumber = interval
+		rpi.Interval = IntervalTime(interval)
+
+		padded := padInterval(interval)
+		copy(pad, padded[:])
+		cipher.Encrypt(rpi.ID, pad)
+		rpis[rp] = rpi
 	}
 
 	return rpis, nil
@@ -111,30 +327,180 @@ func NewRollingProximityIdentifier(rpiKey []byte, interval int) (*RollingProximi
 	}
 
 	rpi := &RollingProximityIdentifier{
-		ID:       make([]byte, 16),
-		Interval: time.Unix(int64(interval*600), 0),
+		ID:             make([]byte, 16),
+		IntervalNumber: interval,
+		Interval:       IntervalTime(interval),
 	}
 
-	cipher.Encrypt(rpi.ID, padInterval(interval))
+	pad := padInterval(interval)
+	cipher.Encrypt(rpi.ID, pad[:])
 
 	return rpi, nil
 }
 
+// timeLocation is the location of the times in the outputs
+var timeLocation = time.UTC
+
+// SetTimeLocation sets the location of the times in all the outputs (e.g. "UTC", "Local", "Europe/Rome")
+func SetTimeLocation(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	timeLocation = loc
+	return nil
+}
+
+// IntervalTime returns the start time of the EN interval number, in the location set with SetTimeLocation
+func IntervalTime(interval int) time.Time {
+	return time.Unix(int64(interval)*600, 0).In(timeLocation)
+}
+
 // JSONTime is an alias used to format the time.Time
 type JSONTime time.Time
 
-// MarshalJSON is used to override the default marshalJSON
+// MarshalJSON is used to override the default marshalJSON.
+// The date is always the UTC day, since the EN days are UTC days.
 func (t JSONTime) MarshalJSON() ([]byte, error) {
-	jsonTime := fmt.Sprintf(`"%s"`, time.Time(t).Format("2006-01-02"))
+	jsonTime := fmt.Sprintf(`"%s"`, t.String())
 	return []byte(jsonTime), nil
 }
 
+// String returns the UTC day of the time
+func (t JSONTime) String() string {
+	return time.Time(t).UTC().Format("2006-01-02")
+}
+
+// IDFormat is the format of the IDs in the outputs
+type IDFormat string
+
+// ID formats supported by the outputs
+const (
+	IDFormatBase64    IDFormat = "base64"
+	IDFormatBase64URL IDFormat = "base64url"
+	IDFormatHex       IDFormat = "hex"
+	IDFormatIntArray  IDFormat = "int-array"
+)
+
+// ParseIDFormat returns the IDFormat with the name
+func ParseIDFormat(name string) (IDFormat, error) {
+	switch f := IDFormat(name); f {
+	case IDFormatBase64, IDFormatBase64URL, IDFormatHex, IDFormatIntArray:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown id format [%s]", name)
+}
+
+// Format returns the representation of the ID in the format. The empty format is base64.
+func (f IDFormat) Format(id ID) string {
+	switch f {
+	case IDFormatBase64URL:
+		return id.ToBase64URL()
+	case IDFormatHex:
+		return strings.Join(id.ToHEX(), "")
+	case IDFormatIntArray:
+		b, _ := json.Marshal(id.ToInt())
+		return string(b)
+	}
+	return id.ToBase64()
+}
+
+// Parse parses an ID written in the format. The empty format is base64.
+func (f IDFormat) Parse(s string) (ID, error) {
+	var id []byte
+	var err error
+
+	switch f {
+	case IDFormatBase64URL:
+		id, err = base64.URLEncoding.DecodeString(s)
+	case IDFormatHex:
+		id, err = hex.DecodeString(s)
+	case IDFormatIntArray:
+		id, err = parseIntArrayID([]byte(s))
+	default:
+		f = IDFormatBase64
+		id, err = base64.StdEncoding.DecodeString(s)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s id '%s': %s", f, s, err)
+	}
+	return ID(id), nil
+}
+
+// ParseJSON parses an ID written in JSON, as a string in the format or as an array of ints
+func (f IDFormat) ParseJSON(b []byte) (ID, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("[")) {
+		return parseIntArrayID(b)
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return f.Parse(s)
+}
+
+// ID returns the FormattedID of the id
+func (f IDFormat) ID(id ID) FormattedID {
+	return FormattedID{id: id, format: f}
+}
+
+// FormattedID is an ID written in an IDFormat
+type FormattedID struct {
+	id     ID
+	format IDFormat
+}
+
+// MarshalJSON writes the ID as a string in the format, or as an array of ints
+func (id FormattedID) MarshalJSON() ([]byte, error) {
+	if id.format == IDFormatIntArray {
+		return json.Marshal(id.id.ToInt())
+	}
+	return json.Marshal(id.String())
+}
+
+// String returns the representation of the ID in the format
+func (id FormattedID) String() string {
+	return id.format.Format(id.id)
+}
+
+// parseIntArrayID parses an ID written as a JSON array of ints
+func parseIntArrayID(b []byte) (ID, error) {
+	var ints []int
+	if err := json.Unmarshal(b, &ints); err != nil {
+		return nil, err
+	}
+	id := make(ID, 0, len(ints))
+	for _, i := range ints {
+		if i < 0 || i > 255 {
+			return nil, fmt.Errorf("invalid id %s", b)
+		}
+		id = append(id, byte(i))
+	}
+	return id, nil
+}
+
 // ID is an alias for an ID made of []byte
 type ID []byte
 
-// MarshalJSON is used to override the default marshalJSON
+// MarshalJSON writes the ID as a base64 string
 func (id ID) MarshalJSON() ([]byte, error) {
-	return []byte(fmt.Sprintf(`"%s"`, id.ToBase64())), nil
+	return json.Marshal(id.ToBase64())
+}
+
+// UnmarshalJSON parses an ID written as a base64 string or as an array of ints
+func (id *ID) UnmarshalJSON(b []byte) error {
+	parsed, err := IDFormatBase64.ParseJSON(b)
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// String returns the base64 representation of the ID
+func (id ID) String() string {
+	return id.ToBase64()
 }
 
 // ToBase64 returns the string representation of a []byte
@@ -142,6 +508,11 @@ func (id ID) ToBase64() string {
 	return base64.StdEncoding.EncodeToString(id)
 }
 
+// ToBase64URL returns the URL safe base64 representation of a []byte
+func (id ID) ToBase64URL() string {
+	return base64.URLEncoding.EncodeToString(id)
+}
+
 // ToHEX returns the hex array representation of a []byte
 func (id ID) ToHEX() []string {
 	hexArr := make([]string, 0)
@@ -163,42 +534,126 @@ func (id ID) ToInt() []int {
 
 // TemporaryExposureKey is the daily tracing key
 type TemporaryExposureKey struct {
-	ID                   ID `json:"ID"`
-	Date                 JSONTime
-	rollingStartInterval int
-	rollingPeriod        int
-	RPIs                 []*RollingProximityIdentifier `json:",omitempty"`
+	ID                         ID `json:"ID"`
+	Date                       JSONTime
+	RollingStartIntervalNumber int
+	RollingPeriod              int
+	RPIs                       []*RollingProximityIdentifier `json:",omitempty"`
+	// PaddingScore is the likelihood of the key being a padding (fake) key, if computed
+	PaddingScore float64 `json:",omitempty"`
+	// Origin and VisitedCountries are set only for the keys of an EFGS DiagnosisKeyBatch
+	Origin           string   `json:",omitempty"`
+	VisitedCountries []string `json:",omitempty"`
+	lazyRPIs         bool
 }
 
 // NewTemporaryExposureKey returns a Temporary Exposure Key
 func NewTemporaryExposureKey(id []byte, rollingStartInterval, rollingPeriod int) *TemporaryExposureKey {
 	return &TemporaryExposureKey{
-		ID:                   id,
-		Date:                 JSONTime(time.Unix(int64(rollingStartInterval*600), 0)),
-		rollingStartInterval: rollingStartInterval,
-		rollingPeriod:        rollingPeriod,
-		RPIs:                 make([]*RollingProximityIdentifier, 0),
+		ID:                         id,
+		Date:                       JSONTime(IntervalTime(rollingStartInterval)),
+		RollingStartIntervalNumber: rollingStartInterval,
+		RollingPeriod:              rollingPeriod,
+		RPIs:                       make([]*RollingProximityIdentifier, 0),
+	}
+}
+
+// ReadTEKs reads the keys written by decode as json or ndjson, with the IDs in the format. Only the ID,
+// the rolling start interval number and the rolling period of the keys are read, and they are required:
+// the rows per RPI, that have no rolling start interval number and rolling period, are rejected.
+func ReadTEKs(r io.Reader, format IDFormat) ([]*TemporaryExposureKey, error) {
+	type tekJSON struct {
+		ID                         json.RawMessage
+		RollingStartIntervalNumber int
+		RollingPeriod              int
+	}
+	var keys []tekJSON
+
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	if first, err := peekNonSpace(br); err == io.EOF {
+		return nil, errors.New("no keys")
+	} else if err != nil {
+		return nil, err
+	} else if first == '[' {
+		if err := dec.Decode(&keys); err != nil {
+			return nil, err
+		}
+	} else {
+		for dec.More() {
+			var key tekJSON
+			if err := dec.Decode(&key); err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	teks := make([]*TemporaryExposureKey, 0, len(keys))
+	for _, k := range keys {
+		id, err := format.ParseJSON(k.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(id) != 16 {
+			return nil, fmt.Errorf("invalid key %s of %d bytes", format.Format(id), len(id))
+		}
+		if k.RollingStartIntervalNumber <= 0 {
+			return nil, fmt.Errorf("key %s without a rolling start interval number: is it a row per RPI?", format.Format(id))
+		}
+		if k.RollingPeriod < 1 || k.RollingPeriod > 144 {
+			return nil, fmt.Errorf("key %s with an invalid rolling period %d", format.Format(id), k.RollingPeriod)
+		}
+		teks = append(teks, NewTemporaryExposureKey(id, k.RollingStartIntervalNumber, k.RollingPeriod))
 	}
+	if len(teks) == 0 {
+		return nil, errors.New("no keys")
+	}
+	return teks, nil
+}
+
+// peekNonSpace returns the first byte that is not a white space, without consuming it
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, r.UnreadByte()
+		}
+	}
+}
+
+// LoadRPIs derives the Rolling Proximity Identifiers of a key decoded with the RPIsLazy mode.
+// It does nothing if the RPIs are already derived, or if the key was not decoded lazily.
+func (tek *TemporaryExposureKey) LoadRPIs() error {
+	if !tek.lazyRPIs {
+		return nil
+	}
+	if err := DecodeTEK(tek); err != nil {
+		return err
+	}
+	tek.lazyRPIs = false
+	return nil
 }
 
 // RollingProximityIdentifier is the bluetooth pseudorandom identifier
 type RollingProximityIdentifier struct {
-	ID       ID        `json:"ID"`
-	Interval time.Time `json:"Interval"`
+	ID             ID        `json:"ID"`
+	IntervalNumber int       `json:"IntervalNumber"`
+	Interval       time.Time `json:"Interval"`
 }
 
 // padInterval is used to creates the padding array for the specified interval
-func padInterval(interval int) []byte {
+func padInterval(interval int) [16]byte {
 	// EN-RPI000000
-	pad := []byte("EN-RPI")
-	for i := 0; i < 6; i++ {
-		pad = append(pad, 0)
-	}
+	pad := [16]byte{'E', 'N', '-', 'R', 'P', 'I'}
 
-	pad = append(pad, byte(interval&0xFF))
-	pad = append(pad, byte(interval>>8&0xFF))
-	pad = append(pad, byte(interval>>16&0xFF))
-	pad = append(pad, byte(interval>>24&0xFF))
+	pad[12] = byte(interval & 0xFF)
+	pad[13] = byte(interval >> 8 & 0xFF)
+	pad[14] = byte(interval >> 16 & 0xFF)
+	pad[15] = byte(interval >> 24 & 0xFF)
 
 	return pad
 }
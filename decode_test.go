@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"gaen/export"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/hkdf"
+	"google.golang.org/protobuf/proto"
+)
+
+// benchmarkExport returns an export with n random keys, valid for a whole day
+func benchmarkExport(b *testing.B, n int) *export.TemporaryExposureKeyExport {
+	exp := &export.TemporaryExposureKeyExport{}
+	for i := 0; i < n; i++ {
+		key := make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			b.Fatal(err)
+		}
+		exp.Keys = append(exp.Keys, &export.TemporaryExposureKey{
+			KeyData:                    key,
+			RollingStartIntervalNumber: proto.Int32(2668896),
+			RollingPeriod:              proto.Int32(144),
+		})
+	}
+	return exp
+}
+
+// knownTEK is the key of the README example, whose RPIs were computed with an independent
+// implementation of the EN cryptography specification (HKDF-SHA256 and AES-128)
+const knownTEK = "+wK7aDl5cTC2wbZ4Ux6bvw=="
+
+var knownRPIs = []struct {
+	rp  int
+	rpi string
+}{
+	{0, "GkUh9M/fYslxaxucp0ayWg=="},
+	{1, "72eL1vRaRXuRfTFTnR6gDA=="},
+	{4, "N99nmdxpfAvk0ByUGWI6EQ=="},
+	{143, "l3HED3/ZIqVrenDLYWPrmA=="},
+}
+
+// newKnownTEK returns the TemporaryExposureKey of the knownTEK, valid for the whole day
+func newKnownTEK(t *testing.T) *TemporaryExposureKey {
+	id, err := base64.StdEncoding.DecodeString(knownTEK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTemporaryExposureKey(id, 2668896, 144)
+}
+
+// checkKnownRPIs checks the RPIs of the knownTEK
+func checkKnownRPIs(t *testing.T, tek *TemporaryExposureKey) {
+	t.Helper()
+	if len(tek.RPIs) != 144 {
+		t.Fatalf("got %d RPIs, want 144", len(tek.RPIs))
+	}
+	for _, known := range knownRPIs {
+		rpi := tek.RPIs[known.rp]
+		if got := rpi.ID.ToBase64(); got != known.rpi {
+			t.Errorf("RPI %d: got %s, want %s", known.rp, got, known.rpi)
+		}
+		if rpi.IntervalNumber != 2668896+known.rp {
+			t.Errorf("RPI %d: got interval number %d, want %d", known.rp, rpi.IntervalNumber, 2668896+known.rp)
+		}
+	}
+}
+
+func TestDecodeTEK(t *testing.T) {
+	tek := newKnownTEK(t)
+	if err := DecodeTEK(tek); err != nil {
+		t.Fatal(err)
+	}
+	checkKnownRPIs(t, tek)
+
+	// a single RPI is derived exactly as the ones of the whole key
+	rpi, err := NewRollingProximityIdentifier(rpiKeyOf(t, tek.ID), 2668896+4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rpi.ID, tek.RPIs[4].ID) {
+		t.Errorf("got %s, want %s", rpi.ID, tek.RPIs[4].ID)
+	}
+}
+
+func TestDecodeTEKs(t *testing.T) {
+	// more keys than workers, with the known key in the middle and different rolling periods
+	teks := make([]*TemporaryExposureKey, 0, 501)
+	for i := 0; i < 500; i++ {
+		if i == 250 {
+			teks = append(teks, newKnownTEK(t))
+		}
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			t.Fatal(err)
+		}
+		teks = append(teks, NewTemporaryExposureKey(id, 2668896+i, 1+i%144))
+	}
+
+	if err := DecodeTEKs(teks); err != nil {
+		t.Fatal(err)
+	}
+	checkKnownRPIs(t, teks[250])
+
+	// every key has its own RPIs, as derived one key at a time
+	for i, tek := range teks {
+		want := NewTemporaryExposureKey(tek.ID, tek.RollingStartIntervalNumber, tek.RollingPeriod)
+		if err := DecodeTEK(want); err != nil {
+			t.Fatal(err)
+		}
+		if len(tek.RPIs) != len(want.RPIs) {
+			t.Fatalf("key %d: got %d RPIs, want %d", i, len(tek.RPIs), len(want.RPIs))
+		}
+		for j := range want.RPIs {
+			if !bytes.Equal(tek.RPIs[j].ID, want.RPIs[j].ID) || tek.RPIs[j].IntervalNumber != want.RPIs[j].IntervalNumber {
+				t.Fatalf("key %d: RPI %d differs", i, j)
+			}
+		}
+	}
+}
+
+func TestKeyIteratorOrder(t *testing.T) {
+	known := newKnownTEK(t)
+	exp := &export.TemporaryExposureKeyExport{}
+	for i := 0; i < 2*keyBatchSize+10; i++ {
+		key := make([]byte, 16)
+		if _, err := rand.Read(key); err != nil {
+			t.Fatal(err)
+		}
+		if i == keyBatchSize+1 {
+			key = known.ID
+		}
+		exp.Keys = append(exp.Keys, &export.TemporaryExposureKey{
+			KeyData:                    key,
+			RollingStartIntervalNumber: proto.Int32(2668896),
+			RollingPeriod:              proto.Int32(144),
+		})
+	}
+
+	for _, mode := range []string{RPIsEager, RPIsLazy} {
+		it, err := NewKeyIterator(exp, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := 0
+		for ; it.Next(); i++ {
+			tek := it.Key()
+			if !bytes.Equal(tek.ID, exp.Keys[i].KeyData) {
+				t.Fatalf("%s: key %d out of order", mode, i)
+			}
+			if i == keyBatchSize+1 {
+				if err := tek.LoadRPIs(); err != nil {
+					t.Fatal(err)
+				}
+				checkKnownRPIs(t, tek)
+			}
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if i != len(exp.Keys) {
+			t.Errorf("%s: got %d keys, want %d", mode, i, len(exp.Keys))
+		}
+	}
+}
+
+// rpiKeyOf returns the RPI key of the TEK
+func rpiKeyOf(t *testing.T, tek ID) []byte {
+	rpiKey := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, tek, nil, []byte("EN-RPIK")), rpiKey); err != nil {
+		t.Fatal(err)
+	}
+	return rpiKey
+}
+
+func BenchmarkNewRollingProximityIdentifier(b *testing.B) {
+	rpiKey := make([]byte, 16)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewRollingProximityIdentifier(rpiKey, 2668896); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNewRollingProximityIdentifiers(b *testing.B) {
+	rpiKey := make([]byte, 16)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewRollingProximityIdentifiers(rpiKey, 2668896, 144); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeTEK(b *testing.B) {
+	tek := NewTemporaryExposureKey(make([]byte, 16), 2668896, 144)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := DecodeTEK(tek); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeExport(b *testing.B) {
+	exp := benchmarkExport(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeExport(exp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeyIteratorEager(b *testing.B) {
+	exp := benchmarkExport(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		it, err := NewKeyIterator(exp, RPIsEager)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for it.Next() {
+		}
+		if err := it.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"gaen/export"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ExportDiff is the difference between two sets of exports
+type ExportDiff struct {
+	Added          []DiffKey
+	Removed        []DiffKey
+	Changed        []DiffChange
+	Metadata       []DiffChange
+	ExportsAdded   []string
+	ExportsRemoved []string
+}
+
+// DiffKey is a key added or removed
+type DiffKey struct {
+	ID     FormattedID
+	Fields map[string]string
+}
+
+// DiffChange is a field of a key or of an export that changed
+type DiffChange struct {
+	ID     *FormattedID `json:",omitempty"`
+	Export string       `json:",omitempty"`
+	Field  string
+	A      string
+	B      string
+}
+
+// keyFields are the compared fields of a key, in order
+var keyFields = []string{
+	"rolling_start_interval_number",
+	"rolling_period",
+	"transmission_risk_level",
+	"report_type",
+	"days_since_onset_of_symptoms",
+	"revised",
+}
+
+// exportFields are the compared metadata fields of an export, in order
+var exportFields = []string{
+	"start_timestamp",
+	"end_timestamp",
+	"region",
+	"batch_num",
+	"batch_size",
+	"signature_infos",
+}
+
+// diffSide is one of the two sides of a diff
+type diffSide struct {
+	keys     map[string]map[string]string
+	order    []string
+	metadata map[string]map[string]string
+	exports  []string
+}
+
+// DiffExports compares two export files or two download folders, with the IDs of the keys in the format
+func DiffExports(a, b string, format IDFormat) (*ExportDiff, error) {
+	sideA, err := loadDiffSide(a)
+	if err != nil {
+		return nil, err
+	}
+	sideB, err := loadDiffSide(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// two single files are compared even if their names differ
+	if len(sideA.exports) == 1 && len(sideB.exports) == 1 {
+		sideA.metadata = map[string]map[string]string{"": sideA.metadata[sideA.exports[0]]}
+		sideA.exports = []string{""}
+		sideB.metadata = map[string]map[string]string{"": sideB.metadata[sideB.exports[0]]}
+		sideB.exports = []string{""}
+	}
+
+	diff := &ExportDiff{
+		Added:          make([]DiffKey, 0),
+		Removed:        make([]DiffKey, 0),
+		Changed:        make([]DiffChange, 0),
+		Metadata:       make([]DiffChange, 0),
+		ExportsAdded:   make([]string, 0),
+		ExportsRemoved: make([]string, 0),
+	}
+
+	for _, id := range sideA.order {
+		fieldsA := sideA.keys[id]
+		fieldsB, ok := sideB.keys[id]
+		if !ok {
+			diff.Removed = append(diff.Removed, DiffKey{ID: format.ID(ID(id)), Fields: fieldsA})
+			continue
+		}
+		for _, field := range keyFields {
+			if fieldsA[field] != fieldsB[field] {
+				formatted := format.ID(ID(id))
+				diff.Changed = append(diff.Changed, DiffChange{ID: &formatted, Field: field, A: fieldsA[field], B: fieldsB[field]})
+			}
+		}
+	}
+	for _, id := range sideB.order {
+		if _, ok := sideA.keys[id]; !ok {
+			diff.Added = append(diff.Added, DiffKey{ID: format.ID(ID(id)), Fields: sideB.keys[id]})
+		}
+	}
+
+	for _, name := range sideA.exports {
+		metaA := sideA.metadata[name]
+		metaB, ok := sideB.metadata[name]
+		if !ok {
+			diff.ExportsRemoved = append(diff.ExportsRemoved, name)
+			continue
+		}
+		for _, field := range exportFields {
+			if metaA[field] != metaB[field] {
+				diff.Metadata = append(diff.Metadata, DiffChange{Export: name, Field: field, A: metaA[field], B: metaB[field]})
+			}
+		}
+	}
+	for _, name := range sideB.exports {
+		if _, ok := sideA.metadata[name]; !ok {
+			diff.ExportsAdded = append(diff.ExportsAdded, name)
+		}
+	}
+
+	return diff, nil
+}
+
+// Empty returns true if there are no differences
+func (d *ExportDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 &&
+		len(d.Metadata) == 0 && len(d.ExportsAdded) == 0 && len(d.ExportsRemoved) == 0
+}
+
+// WriteText writes the diff with a line for every difference
+func (d *ExportDiff) WriteText(w io.Writer) error {
+	for _, name := range d.ExportsRemoved {
+		fmt.Fprintf(w, "- export %s\n", name)
+	}
+	for _, name := range d.ExportsAdded {
+		fmt.Fprintf(w, "+ export %s\n", name)
+	}
+	for _, c := range d.Metadata {
+		name := c.Export
+		if name == "" {
+			name = "export"
+		}
+		fmt.Fprintf(w, "~ %s %s: %s -> %s\n", name, c.Field, c.A, c.B)
+	}
+	for _, k := range d.Removed {
+		fmt.Fprintf(w, "- %s\n", k.ID)
+	}
+	for _, k := range d.Added {
+		fmt.Fprintf(w, "+ %s\n", k.ID)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(w, "~ %s %s: %s -> %s\n", c.ID, c.Field, c.A, c.B)
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d changed keys, %d metadata changes\n",
+		len(d.Added), len(d.Removed), len(d.Changed), len(d.Metadata))
+	return err
+}
+
+// loadDiffSide loads the keys and the metadata of the exports of a path
+func loadDiffSide(path string) (*diffSide, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	files, err := ExportFiles([]string{path})
+	if err != nil {
+		return nil, err
+	}
+
+	side := &diffSide{
+		keys:     make(map[string]map[string]string),
+		order:    make([]string, 0),
+		metadata: make(map[string]map[string]string),
+		exports:  make([]string, 0),
+	}
+
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+
+		name := file.Path
+		if file.App != "" {
+			name = file.App + "/" + file.Export
+		}
+		side.exports = append(side.exports, name)
+		side.metadata[name] = exportMetadata(exp)
+
+		side.addKeys(exp.Keys, false)
+		side.addKeys(exp.RevisedKeys, true)
+	}
+
+	sort.Strings(side.exports)
+	return side, nil
+}
+
+// addKeys adds the keys to the side. A revised key replaces the original one.
+func (s *diffSide) addKeys(keys []*export.TemporaryExposureKey, revised bool) {
+	for _, key := range keys {
+		id := string(key.KeyData)
+		if _, ok := s.keys[id]; !ok {
+			s.order = append(s.order, id)
+		} else if !revised {
+			continue
+		}
+		s.keys[id] = keyMetadata(key, revised)
+	}
+}
+
+// keyMetadata returns the compared fields of a key
+func keyMetadata(key *export.TemporaryExposureKey, revised bool) map[string]string {
+	reportType := unset
+	if key.ReportType != nil {
+		reportType = key.GetReportType().String()
+	}
+	return map[string]string{
+		"rolling_start_interval_number": optionalInt(key.RollingStartIntervalNumber),
+		"rolling_period":                optionalInt(key.RollingPeriod),
+		"transmission_risk_level":       optionalInt(key.TransmissionRiskLevel),
+		"report_type":                   reportType,
+		"days_since_onset_of_symptoms":  optionalInt(key.DaysSinceOnsetOfSymptoms),
+		"revised":                       strconv.FormatBool(revised),
+	}
+}
+
+// exportMetadata returns the compared metadata fields of an export
+func exportMetadata(exp *export.TemporaryExposureKeyExport) map[string]string {
+	timestamp := func(v *uint64) string {
+		if v == nil {
+			return unset
+		}
+		return time.Unix(int64(*v), 0).UTC().Format(time.RFC3339)
+	}
+
+	signatures := ""
+	for i, si := range exp.SignatureInfos {
+		if i > 0 {
+			signatures += ","
+		}
+		signatures += fmt.Sprintf("%s/%s/%s", si.GetVerificationKeyId(), si.GetVerificationKeyVersion(), si.GetSignatureAlgorithm())
+	}
+
+	region := unset
+	if exp.Region != nil {
+		region = exp.GetRegion()
+	}
+
+	return map[string]string{
+		"start_timestamp": timestamp(exp.StartTimestamp),
+		"end_timestamp":   timestamp(exp.EndTimestamp),
+		"region":          region,
+		"batch_num":       optionalInt(exp.BatchNum),
+		"batch_size":      optionalInt(exp.BatchSize),
+		"signature_infos": signatures,
+	}
+}
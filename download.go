@@ -3,9 +3,11 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Downloader is the interface that can be used to download a GAEN export
@@ -14,15 +16,32 @@ type Downloader interface {
 	GetURL(export string) string
 }
 
+// DownloadOptions are the options used while downloading an export
+type DownloadOptions struct {
+	// Limits are the limits enforced while extracting the export zip
+	Limits UnzipLimits
+	// ArchiveDir, if set, is the folder of the Archive where the original zips are kept
+	ArchiveDir string
+	// BaseURL, if set, replaces the base url of the app backend
+	BaseURL string
+}
+
+// DefaultDownloadOptions returns the default DownloadOptions
+func DefaultDownloadOptions() DownloadOptions {
+	return DownloadOptions{
+		Limits: DefaultUnzipLimits,
+	}
+}
+
 // Download will download the 'app' export in the workDir/app folder
-func Download(workDir, app string) error {
-	dwln, err := DownloaderFactory(app)
+func Download(workDir, app string, opts DownloadOptions) error {
+	dwln, err := DownloaderFactory(app, opts.BaseURL)
 	if err != nil {
 		return err
 	}
 
-	path := filepath.Join(workDir, app)
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+	appDir := filepath.Join(workDir, app)
+	if err := os.MkdirAll(appDir, os.ModePerm); err != nil {
 		return err
 	}
 
@@ -30,24 +49,111 @@ func Download(workDir, app string) error {
 	if err != nil {
 		return err
 	}
-	latestURL := dwln.GetURL(latest)
 
-	filepath := filepath.Join(workDir, app, latest)
-	filepathZip := filepath + ".zip"
+	return DownloadExport(appDir, app, latest, dwln.GetURL(latest), opts)
+}
+
+// DownloadExport downloads and extracts the export from the url into the appDir/export folder.
+// Everything is written in a temporary folder that is renamed only when the export
+// has been fully extracted and its manifest written, so a crash never leaves a half-written export.
+func DownloadExport(appDir, app, export, url string, opts DownloadOptions) error {
+	tmpDir, err := ioutil.TempDir(appDir, tmpDirPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	zipPath := filepath.Join(tmpDir, export+".zip")
+	if err = DownloadZip(url, zipPath, opts.Limits.MaxZipSize()); err != nil {
+		return err
+	}
+
+	zipSum, err := sha256File(zipPath)
+	if err != nil {
+		return err
+	}
+
+	manifest := &Manifest{
+		App:       app,
+		Export:    export,
+		URL:       url,
+		FetchedAt: time.Now().UTC(),
+		ZipSHA256: zipSum,
+		Files:     make(map[string]string),
+	}
+
+	// the zip is archived as served, before it is validated, so also a rejected zip is kept
+	if opts.ArchiveDir != "" {
+		if err := archiveZip(opts.ArchiveDir, zipPath, manifest); err != nil {
+			return err
+		}
+	}
+
+	extractDir := filepath.Join(tmpDir, export)
+	filenames, err := UnzipWithLimits(zipPath, extractDir, opts.Limits)
+	if err != nil {
+		return err
+	}
+
+	if err := manifest.AddFiles(extractDir, filenames); err != nil {
+		return err
+	}
+	if err := WriteManifest(extractDir, manifest); err != nil {
+		return err
+	}
+
+	if err := syncDir(extractDir); err != nil {
+		return err
+	}
 
-	if err = DownloadZip(latestURL, filepathZip); err != nil {
+	// a previous download of the export is moved aside in the temporary folder, and not removed,
+	// so after a crash it is still in the stale temporary folder reported by fsck
+	dest := filepath.Join(appDir, export)
+	previous := filepath.Join(tmpDir, "previous")
+	if _, err := os.Stat(dest); err == nil {
+		if err := os.Rename(dest, previous); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(extractDir, dest); err != nil {
+		if _, statErr := os.Stat(previous); statErr == nil {
+			os.Rename(previous, dest)
+		}
 		return err
 	}
+	return syncDir(appDir)
+}
 
-	if _, err := Unzip(filepathZip, filepath); err != nil {
+// syncDir flushes to disk the files of the dir folder, and the folder itself
+func syncDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
 		return err
 	}
+	for _, f := range files {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if err := syncFile(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return syncFile(dir)
+}
 
-	return os.Remove(filepathZip)
+// syncFile flushes the file, or folder, to disk
+func syncFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
 }
 
-// DownloadZip downloads a zip from the url into the specified zipPath
-func DownloadZip(url, zipPath string) error {
+// DownloadZip downloads a zip from the url into the specified zipPath. The download fails
+// if the zip is bigger than maxSize bytes, unless maxSize is negative.
+func DownloadZip(url, zipPath string, maxSize int64) error {
 	resp, err := http.Get(url)
 	if err != nil {
 		return err
@@ -64,6 +170,43 @@ func DownloadZip(url, zipPath string) error {
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
-	return err
+	// the body is bounded, so a hostile server cannot fill the disk before the zip is checked
+	if maxSize < 0 {
+		_, err = io.Copy(out, resp.Body)
+	} else {
+		var written int64
+		written, err = io.Copy(out, io.LimitReader(resp.Body, maxSize+1))
+		if err == nil && written > maxSize {
+			err = fmt.Errorf("error downloading zip: more than %d bytes", maxSize)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// archiveZip stores the zip in the archive, recording it with the manifest details
+func archiveZip(archiveDir, zipPath string, manifest *Manifest) error {
+	archive, err := NewArchive(archiveDir)
+	if err != nil {
+		return err
+	}
+
+	sum, size, err := archive.Put(zipPath)
+	if err != nil {
+		return err
+	}
+	if sum != manifest.ZipSHA256 {
+		return fmt.Errorf("zip %s changed while archiving", zipPath)
+	}
+
+	return archive.Record(ArchiveEntry{
+		App:       manifest.App,
+		Export:    manifest.Export,
+		URL:       manifest.URL,
+		FetchedAt: manifest.FetchedAt,
+		SHA256:    sum,
+		Size:      size,
+	})
 }
@@ -16,23 +16,35 @@ const (
 	SwissCovidURL = "https://www.pt.bfs.admin.ch"
 )
 
-// DownloaderFactory returns the Downloader for the specified app
-func DownloaderFactory(app string) (Downloader, error) {
+// DownloaderFactory returns the Downloader for the specified app.
+// If baseURL is not empty it replaces the base url of the app backend (e.g. to use a mock server).
+func DownloaderFactory(app, baseURL string) (Downloader, error) {
 	switch app {
 	case "immuni":
-		return ImmuniDownloader{}, nil
+		return ImmuniDownloader{BaseURL: baseURL}, nil
 	case "swisscovid":
-		return SwissCovidDownloader{}, nil
+		return SwissCovidDownloader{BaseURL: baseURL}, nil
 	}
 	return nil, fmt.Errorf("unknown app [%s]", app)
 }
 
 // ImmuniDownloader is the downloader for the Immuni app
-type ImmuniDownloader struct{}
+type ImmuniDownloader struct {
+	// BaseURL is the base url of the backend, ImmuniURL if empty
+	BaseURL string
+}
+
+// baseURL returns the base url of the backend
+func (d ImmuniDownloader) baseURL() string {
+	if d.BaseURL == "" {
+		return ImmuniURL
+	}
+	return d.BaseURL
+}
 
 // GetLatestExport returns the latest Immuni export
 func (d ImmuniDownloader) GetLatestExport() (string, error) {
-	resp, err := http.Get(ImmuniURL + "/v1/keys/index")
+	resp, err := http.Get(d.baseURL() + "/v1/keys/index")
 	if err != nil {
 		return "", err
 	}
@@ -57,13 +69,24 @@ func (d ImmuniDownloader) GetLatestExport() (string, error) {
 
 // GetURL returns the Immuni URL where to download the export
 func (d ImmuniDownloader) GetURL(export string) string {
-	return ImmuniURL + "/v1/keys/" + export
+	return d.baseURL() + "/v1/keys/" + export
 }
 
-// SwissCovidDownloader is the downloader for the Immuni app
-type SwissCovidDownloader struct{}
+// SwissCovidDownloader is the downloader for the SwissCovid app
+type SwissCovidDownloader struct {
+	// BaseURL is the base url of the backend, SwissCovidURL if empty
+	BaseURL string
+}
+
+// baseURL returns the base url of the backend
+func (d SwissCovidDownloader) baseURL() string {
+	if d.BaseURL == "" {
+		return SwissCovidURL
+	}
+	return d.BaseURL
+}
 
-// GetLatestExport returns the latest SwissCovid export
+// GetLatestExport returns the latest SwissCovid export, probing the exports of today and of the two previous days (UTC)
 func (d SwissCovidDownloader) GetLatestExport() (string, error) {
 	retry := 0
 
@@ -90,5 +113,5 @@ func (d SwissCovidDownloader) GetLatestExport() (string, error) {
 
 // GetURL returns the SwissCovid URL where to download the export
 func (d SwissCovidDownloader) GetURL(export string) string {
-	return SwissCovidURL + "/v1/gaen/exposed/" + export
+	return d.baseURL() + "/v1/gaen/exposed/" + export
 }
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gaen/efgs"
+	"gaen/export"
+	"io/ioutil"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// UnmarshalDiagnosisKeyBatch unmarshal an EFGS DiagnosisKeyBatch, as downloaded from the gateway
+func UnmarshalDiagnosisKeyBatch(in []byte) (*efgs.DiagnosisKeyBatch, error) {
+	batch := &efgs.DiagnosisKeyBatch{}
+	if err := proto.Unmarshal(in, batch); err != nil {
+		return nil, err
+	}
+	if len(batch.Keys) == 0 {
+		return nil, errors.New("no diagnosis keys found")
+	}
+	return batch, nil
+}
+
+// Inputs read by ReadExportInput
+const (
+	// InputExport is an export.bin file or an export zip
+	InputExport = "export"
+	// InputEFGS is an EFGS DiagnosisKeyBatch
+	InputEFGS = "efgs"
+)
+
+// ReadExportInput reads the file as the input, returning the export and, for an EFGS DiagnosisKeyBatch,
+// the batch converted to the export. The batch is nil for an export.
+func ReadExportInput(filename, input string) (*export.TemporaryExposureKeyExport, *efgs.DiagnosisKeyBatch, error) {
+	switch input {
+	case InputExport:
+		exp, err := UnmarshalExportFile(filename)
+		return exp, nil, err
+	case InputEFGS:
+		in, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		batch, err := UnmarshalDiagnosisKeyBatch(in)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		return BatchToExport(batch, ""), batch, nil
+	}
+	return nil, nil, fmt.Errorf("unknown input [%s]: use %s or %s", input, InputExport, InputEFGS)
+}
+
+// DSOS ranges of the days since onset of symptoms of the EFGS keys, that encode the symptom status as well
+const (
+	// dsosMax is the maximum number of days since the onset, or since the submission, in every range
+	dsosMax = 14
+	// dsosRangeOffset is the offset, multiplied by the length in days, of the onsets known within a range of days
+	dsosRangeOffset = 100
+	// dsosMaxRangeDays is the maximum length in days of the ranges of the onsets
+	dsosMaxRangeDays = 19
+	// dsosUnknownStatus is the value of a key with an unknown symptom status, submitted on the day of the key
+	dsosUnknownStatus = 4000
+)
+
+// DecodeDSOS decodes the days since onset of symptoms of an EFGS key to the days since onset of an export key.
+// The values in [-14, 14] are the days since the onset, and the values in [100*n-14, 100*n+14], n in [1, 19],
+// are the days since an onset known within a range of n days. The other values, like the days since the submission
+// of the keys with an unknown onset (around 2000), asymptomatic (around 3000) or with an unknown symptom status
+// (around 4000), have no days since onset, and ok is false.
+func DecodeDSOS(dsos int32) (days int32, ok bool) {
+	if dsos >= -dsosMax && dsos <= dsosMax {
+		return dsos, true
+	}
+	for n := int32(1); n <= dsosMaxRangeDays; n++ {
+		if d := dsos - n*dsosRangeOffset; d >= -dsosMax && d <= dsosMax {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// DiagnosisKeyToExportKey maps an EFGS DiagnosisKey to a TemporaryExposureKey of an export.
+// The origin and the visited countries are not part of the export key. The days since onset are decoded with
+// DecodeDSOS, and left unset if the key has none, as the UNKNOWN (or an unknown) report type.
+func DiagnosisKeyToExportKey(key *efgs.DiagnosisKey) *export.TemporaryExposureKey {
+	rollingPeriod := int32(key.GetRollingPeriod())
+	if rollingPeriod == 0 {
+		rollingPeriod = 144
+	}
+	tek := &export.TemporaryExposureKey{
+		KeyData:                    key.GetKeyData(),
+		TransmissionRiskLevel:      proto.Int32(key.GetTransmissionRiskLevel()),
+		RollingStartIntervalNumber: proto.Int32(int32(key.GetRollingStartIntervalNumber())),
+		RollingPeriod:              proto.Int32(rollingPeriod),
+	}
+	if reportType := key.GetReportType(); reportType != efgs.ReportType_UNKNOWN {
+		if _, ok := export.TemporaryExposureKey_ReportType_name[int32(reportType)]; ok {
+			tek.ReportType = export.TemporaryExposureKey_ReportType(reportType).Enum()
+		}
+	}
+	if days, ok := DecodeDSOS(key.GetDaysSinceOnsetOfSymptoms()); ok {
+		tek.DaysSinceOnsetOfSymptoms = proto.Int32(days)
+	}
+	return tek
+}
+
+// ExportKeyToDiagnosisKey maps a TemporaryExposureKey of an export to an EFGS DiagnosisKey.
+// A key without days since onset gets the unknown symptom status (4000), that DecodeDSOS maps back to unset.
+func ExportKeyToDiagnosisKey(key *export.TemporaryExposureKey, origin string, visitedCountries []string) *efgs.DiagnosisKey {
+	dsos := int32(dsosUnknownStatus)
+	if key.DaysSinceOnsetOfSymptoms != nil {
+		dsos = key.GetDaysSinceOnsetOfSymptoms()
+	}
+	return &efgs.DiagnosisKey{
+		KeyData:                    key.GetKeyData(),
+		RollingStartIntervalNumber: uint32(key.GetRollingStartIntervalNumber()),
+		RollingPeriod:              uint32(key.GetRollingPeriod()),
+		TransmissionRiskLevel:      key.GetTransmissionRiskLevel(),
+		VisitedCountries:           visitedCountries,
+		Origin:                     origin,
+		ReportType:                 efgs.ReportType(key.GetReportType()),
+		DaysSinceOnsetOfSymptoms:   dsos,
+	}
+}
+
+// BatchToExport converts an EFGS DiagnosisKeyBatch to a single TemporaryExposureKeyExport, with the keys sorted
+// by key data. If region is empty, the region is the origin of the keys, or it is left empty if they have
+// different origins. The timestamps span the intervals of the keys.
+func BatchToExport(batch *efgs.DiagnosisKeyBatch, region string) *export.TemporaryExposureKeyExport {
+	keys := make([]*export.TemporaryExposureKey, 0, len(batch.Keys))
+	origins := make(map[string]bool)
+	var start, end uint64
+
+	for i, key := range batch.Keys {
+		tek := DiagnosisKeyToExportKey(key)
+		keys = append(keys, tek)
+		origins[key.GetOrigin()] = true
+
+		from := uint64(tek.GetRollingStartIntervalNumber()) * 600
+		to := uint64(tek.GetRollingStartIntervalNumber()+tek.GetRollingPeriod()) * 600
+		if i == 0 || from < start {
+			start = from
+		}
+		if to > end {
+			end = to
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i].KeyData, keys[j].KeyData) < 0
+	})
+
+	if region == "" && len(origins) == 1 {
+		region = batch.Keys[0].GetOrigin()
+	}
+
+	return &export.TemporaryExposureKeyExport{
+		StartTimestamp: proto.Uint64(start),
+		EndTimestamp:   proto.Uint64(end),
+		Region:         proto.String(region),
+		BatchNum:       proto.Int32(1),
+		BatchSize:      proto.Int32(1),
+		Keys:           keys,
+	}
+}
+
+// ExportToBatch converts the keys, and the revised keys, of a TemporaryExposureKeyExport to an EFGS
+// DiagnosisKeyBatch. If origin is empty, the region of the export is used.
+func ExportToBatch(exp *export.TemporaryExposureKeyExport, origin string, visitedCountries []string) (*efgs.DiagnosisKeyBatch, error) {
+	if origin == "" {
+		origin = exp.GetRegion()
+	}
+	if origin == "" {
+		return nil, fmt.Errorf("the export has no region: set the origin of the keys")
+	}
+
+	batch := &efgs.DiagnosisKeyBatch{
+		Keys: make([]*efgs.DiagnosisKey, 0, len(exp.Keys)+len(exp.RevisedKeys)),
+	}
+	for _, keys := range [][]*export.TemporaryExposureKey{exp.Keys, exp.RevisedKeys} {
+		for _, key := range keys {
+			batch.Keys = append(batch.Keys, ExportKeyToDiagnosisKey(key, origin, visitedCountries))
+		}
+	}
+	return batch, nil
+}
@@ -0,0 +1,1165 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.13.0
+// source: gaen.proto
+
+package export
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type DecodeExportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Content of an export zip or of an export.bin file
+	Export []byte `protobuf:"bytes,1,opt,name=export" json:"export,omitempty"`
+	// Derive the Rolling Proximity Identifiers of the keys
+	Rpis *bool `protobuf:"varint,2,opt,name=rpis" json:"rpis,omitempty"`
+}
+
+func (x *DecodeExportRequest) Reset() {
+	*x = DecodeExportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecodeExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecodeExportRequest) ProtoMessage() {}
+
+func (x *DecodeExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecodeExportRequest.ProtoReflect.Descriptor instead.
+func (*DecodeExportRequest) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DecodeExportRequest) GetExport() []byte {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+func (x *DecodeExportRequest) GetRpis() bool {
+	if x != nil && x.Rpis != nil {
+		return *x.Rpis
+	}
+	return false
+}
+
+type DecodedKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key  *TemporaryExposureKey         `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Rpis []*RollingProximityIdentifier `protobuf:"bytes,2,rep,name=rpis" json:"rpis,omitempty"`
+}
+
+func (x *DecodedKey) Reset() {
+	*x = DecodedKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DecodedKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DecodedKey) ProtoMessage() {}
+
+func (x *DecodedKey) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DecodedKey.ProtoReflect.Descriptor instead.
+func (*DecodedKey) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DecodedKey) GetKey() *TemporaryExposureKey {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *DecodedKey) GetRpis() []*RollingProximityIdentifier {
+	if x != nil {
+		return x.Rpis
+	}
+	return nil
+}
+
+type RollingProximityIdentifier struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rpi            []byte `protobuf:"bytes,1,opt,name=rpi" json:"rpi,omitempty"`
+	IntervalNumber *int32 `protobuf:"varint,2,opt,name=interval_number,json=intervalNumber" json:"interval_number,omitempty"`
+}
+
+func (x *RollingProximityIdentifier) Reset() {
+	*x = RollingProximityIdentifier{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RollingProximityIdentifier) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollingProximityIdentifier) ProtoMessage() {}
+
+func (x *RollingProximityIdentifier) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollingProximityIdentifier.ProtoReflect.Descriptor instead.
+func (*RollingProximityIdentifier) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RollingProximityIdentifier) GetRpi() []byte {
+	if x != nil {
+		return x.Rpi
+	}
+	return nil
+}
+
+func (x *RollingProximityIdentifier) GetIntervalNumber() int32 {
+	if x != nil && x.IntervalNumber != nil {
+		return *x.IntervalNumber
+	}
+	return 0
+}
+
+type VerifyExportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Content of an export zip, with the export.bin and export.sig files
+	Export []byte `protobuf:"bytes,1,opt,name=export" json:"export,omitempty"`
+}
+
+func (x *VerifyExportRequest) Reset() {
+	*x = VerifyExportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyExportRequest) ProtoMessage() {}
+
+func (x *VerifyExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyExportRequest.ProtoReflect.Descriptor instead.
+func (*VerifyExportRequest) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyExportRequest) GetExport() []byte {
+	if x != nil {
+		return x.Export
+	}
+	return nil
+}
+
+type VerifyExportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// True if all the signatures are valid
+	Valid      *bool             `protobuf:"varint,1,opt,name=valid" json:"valid,omitempty"`
+	Signatures []*SignatureCheck `protobuf:"bytes,2,rep,name=signatures" json:"signatures,omitempty"`
+}
+
+func (x *VerifyExportResponse) Reset() {
+	*x = VerifyExportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyExportResponse) ProtoMessage() {}
+
+func (x *VerifyExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyExportResponse.ProtoReflect.Descriptor instead.
+func (*VerifyExportResponse) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VerifyExportResponse) GetValid() bool {
+	if x != nil && x.Valid != nil {
+		return *x.Valid
+	}
+	return false
+}
+
+func (x *VerifyExportResponse) GetSignatures() []*SignatureCheck {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+type SignatureCheck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SignatureInfo *SignatureInfo `protobuf:"bytes,1,opt,name=signature_info,json=signatureInfo" json:"signature_info,omitempty"`
+	BatchNum      *int32         `protobuf:"varint,2,opt,name=batch_num,json=batchNum" json:"batch_num,omitempty"`
+	BatchSize     *int32         `protobuf:"varint,3,opt,name=batch_size,json=batchSize" json:"batch_size,omitempty"`
+	Valid         *bool          `protobuf:"varint,4,opt,name=valid" json:"valid,omitempty"`
+	Error         *string        `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
+}
+
+func (x *SignatureCheck) Reset() {
+	*x = SignatureCheck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignatureCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignatureCheck) ProtoMessage() {}
+
+func (x *SignatureCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignatureCheck.ProtoReflect.Descriptor instead.
+func (*SignatureCheck) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SignatureCheck) GetSignatureInfo() *SignatureInfo {
+	if x != nil {
+		return x.SignatureInfo
+	}
+	return nil
+}
+
+func (x *SignatureCheck) GetBatchNum() int32 {
+	if x != nil && x.BatchNum != nil {
+		return *x.BatchNum
+	}
+	return 0
+}
+
+func (x *SignatureCheck) GetBatchSize() int32 {
+	if x != nil && x.BatchSize != nil {
+		return *x.BatchSize
+	}
+	return 0
+}
+
+func (x *SignatureCheck) GetValid() bool {
+	if x != nil && x.Valid != nil {
+		return *x.Valid
+	}
+	return false
+}
+
+func (x *SignatureCheck) GetError() string {
+	if x != nil && x.Error != nil {
+		return *x.Error
+	}
+	return ""
+}
+
+type MatchRPIsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rpis [][]byte `protobuf:"bytes,1,rep,name=rpis" json:"rpis,omitempty"`
+	// Only the keys valid in the time window, in UTC seconds, are derived.
+	// A zero timestamp leaves the window unbounded on that side.
+	FromTimestamp *int64 `protobuf:"varint,2,opt,name=from_timestamp,json=fromTimestamp" json:"from_timestamp,omitempty"`
+	ToTimestamp   *int64 `protobuf:"varint,3,opt,name=to_timestamp,json=toTimestamp" json:"to_timestamp,omitempty"`
+}
+
+func (x *MatchRPIsRequest) Reset() {
+	*x = MatchRPIsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MatchRPIsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchRPIsRequest) ProtoMessage() {}
+
+func (x *MatchRPIsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchRPIsRequest.ProtoReflect.Descriptor instead.
+func (*MatchRPIsRequest) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MatchRPIsRequest) GetRpis() [][]byte {
+	if x != nil {
+		return x.Rpis
+	}
+	return nil
+}
+
+func (x *MatchRPIsRequest) GetFromTimestamp() int64 {
+	if x != nil && x.FromTimestamp != nil {
+		return *x.FromTimestamp
+	}
+	return 0
+}
+
+func (x *MatchRPIsRequest) GetToTimestamp() int64 {
+	if x != nil && x.ToTimestamp != nil {
+		return *x.ToTimestamp
+	}
+	return 0
+}
+
+type MatchRPIsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Matches []*RPIMatch `protobuf:"bytes,1,rep,name=matches" json:"matches,omitempty"`
+}
+
+func (x *MatchRPIsResponse) Reset() {
+	*x = MatchRPIsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MatchRPIsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchRPIsResponse) ProtoMessage() {}
+
+func (x *MatchRPIsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchRPIsResponse.ProtoReflect.Descriptor instead.
+func (*MatchRPIsResponse) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MatchRPIsResponse) GetMatches() []*RPIMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+type RPIMatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Rpi                        []byte       `protobuf:"bytes,1,opt,name=rpi" json:"rpi,omitempty"`
+	Tek                        []byte       `protobuf:"bytes,2,opt,name=tek" json:"tek,omitempty"`
+	IntervalNumber             *int32       `protobuf:"varint,3,opt,name=interval_number,json=intervalNumber" json:"interval_number,omitempty"`
+	RollingStartIntervalNumber *int32       `protobuf:"varint,4,opt,name=rolling_start_interval_number,json=rollingStartIntervalNumber" json:"rolling_start_interval_number,omitempty"`
+	RollingPeriod              *int32       `protobuf:"varint,5,opt,name=rolling_period,json=rollingPeriod" json:"rolling_period,omitempty"`
+	Sources                    []*ExportRef `protobuf:"bytes,6,rep,name=sources" json:"sources,omitempty"`
+}
+
+func (x *RPIMatch) Reset() {
+	*x = RPIMatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RPIMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RPIMatch) ProtoMessage() {}
+
+func (x *RPIMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RPIMatch.ProtoReflect.Descriptor instead.
+func (*RPIMatch) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RPIMatch) GetRpi() []byte {
+	if x != nil {
+		return x.Rpi
+	}
+	return nil
+}
+
+func (x *RPIMatch) GetTek() []byte {
+	if x != nil {
+		return x.Tek
+	}
+	return nil
+}
+
+func (x *RPIMatch) GetIntervalNumber() int32 {
+	if x != nil && x.IntervalNumber != nil {
+		return *x.IntervalNumber
+	}
+	return 0
+}
+
+func (x *RPIMatch) GetRollingStartIntervalNumber() int32 {
+	if x != nil && x.RollingStartIntervalNumber != nil {
+		return *x.RollingStartIntervalNumber
+	}
+	return 0
+}
+
+func (x *RPIMatch) GetRollingPeriod() int32 {
+	if x != nil && x.RollingPeriod != nil {
+		return *x.RollingPeriod
+	}
+	return 0
+}
+
+func (x *RPIMatch) GetSources() []*ExportRef {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type ExportRef struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	App    *string `protobuf:"bytes,1,opt,name=app" json:"app,omitempty"`
+	Export *string `protobuf:"bytes,2,opt,name=export" json:"export,omitempty"`
+	Region *string `protobuf:"bytes,3,opt,name=region" json:"region,omitempty"`
+}
+
+func (x *ExportRef) Reset() {
+	*x = ExportRef{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRef) ProtoMessage() {}
+
+func (x *ExportRef) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRef.ProtoReflect.Descriptor instead.
+func (*ExportRef) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExportRef) GetApp() string {
+	if x != nil && x.App != nil {
+		return *x.App
+	}
+	return ""
+}
+
+func (x *ExportRef) GetExport() string {
+	if x != nil && x.Export != nil {
+		return *x.Export
+	}
+	return ""
+}
+
+func (x *ExportRef) GetRegion() string {
+	if x != nil && x.Region != nil {
+		return *x.Region
+	}
+	return ""
+}
+
+type ListExportsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListExportsRequest) Reset() {
+	*x = ListExportsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListExportsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExportsRequest) ProtoMessage() {}
+
+func (x *ListExportsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExportsRequest.ProtoReflect.Descriptor instead.
+func (*ListExportsRequest) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{10}
+}
+
+type ListExportsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Exports []*ExportInfo `protobuf:"bytes,1,rep,name=exports" json:"exports,omitempty"`
+}
+
+func (x *ListExportsResponse) Reset() {
+	*x = ListExportsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListExportsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListExportsResponse) ProtoMessage() {}
+
+func (x *ListExportsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListExportsResponse.ProtoReflect.Descriptor instead.
+func (*ListExportsResponse) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListExportsResponse) GetExports() []*ExportInfo {
+	if x != nil {
+		return x.Exports
+	}
+	return nil
+}
+
+type ExportInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	App    *string `protobuf:"bytes,1,opt,name=app" json:"app,omitempty"`
+	Export *string `protobuf:"bytes,2,opt,name=export" json:"export,omitempty"`
+	Path   *string `protobuf:"bytes,3,opt,name=path" json:"path,omitempty"`
+	// The export, without the keys and the revised keys
+	Header      *TemporaryExposureKeyExport `protobuf:"bytes,4,opt,name=header" json:"header,omitempty"`
+	Keys        *int32                      `protobuf:"varint,5,opt,name=keys" json:"keys,omitempty"`
+	RevisedKeys *int32                      `protobuf:"varint,6,opt,name=revised_keys,json=revisedKeys" json:"revised_keys,omitempty"`
+}
+
+func (x *ExportInfo) Reset() {
+	*x = ExportInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gaen_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportInfo) ProtoMessage() {}
+
+func (x *ExportInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_gaen_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportInfo.ProtoReflect.Descriptor instead.
+func (*ExportInfo) Descriptor() ([]byte, []int) {
+	return file_gaen_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ExportInfo) GetApp() string {
+	if x != nil && x.App != nil {
+		return *x.App
+	}
+	return ""
+}
+
+func (x *ExportInfo) GetExport() string {
+	if x != nil && x.Export != nil {
+		return *x.Export
+	}
+	return ""
+}
+
+func (x *ExportInfo) GetPath() string {
+	if x != nil && x.Path != nil {
+		return *x.Path
+	}
+	return ""
+}
+
+func (x *ExportInfo) GetHeader() *TemporaryExposureKeyExport {
+	if x != nil {
+		return x.Header
+	}
+	return nil
+}
+
+func (x *ExportInfo) GetKeys() int32 {
+	if x != nil && x.Keys != nil {
+		return *x.Keys
+	}
+	return 0
+}
+
+func (x *ExportInfo) GetRevisedKeys() int32 {
+	if x != nil && x.RevisedKeys != nil {
+		return *x.RevisedKeys
+	}
+	return 0
+}
+
+var File_gaen_proto protoreflect.FileDescriptor
+
+var file_gaen_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x67, 0x61,
+	0x65, 0x6e, 0x1a, 0x1f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x62, 0x2f,
+	0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x2f, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0x41, 0x0a, 0x13, 0x44, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78,
+	0x70, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x65, 0x78, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x70, 0x69, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x04, 0x72, 0x70, 0x69, 0x73, 0x22, 0x6b, 0x0a, 0x0a, 0x44, 0x65, 0x63, 0x6f, 0x64, 0x65,
+	0x64, 0x4b, 0x65, 0x79, 0x12, 0x27, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6f, 0x72, 0x61, 0x72, 0x79, 0x45, 0x78, 0x70,
+	0x6f, 0x73, 0x75, 0x72, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x34, 0x0a,
+	0x04, 0x72, 0x70, 0x69, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x67, 0x61,
+	0x65, 0x6e, 0x2e, 0x52, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x50, 0x72, 0x6f, 0x78, 0x69, 0x6d,
+	0x69, 0x74, 0x79, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65, 0x72, 0x52, 0x04, 0x72,
+	0x70, 0x69, 0x73, 0x22, 0x57, 0x0a, 0x1a, 0x52, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x50, 0x72,
+	0x6f, 0x78, 0x69, 0x6d, 0x69, 0x74, 0x79, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66, 0x69, 0x65,
+	0x72, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x70, 0x69, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x72, 0x70, 0x69, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f,
+	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x2d, 0x0a, 0x13,
+	0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x22, 0x62, 0x0a, 0x14, 0x56,
+	0x65, 0x72, 0x69, 0x66, 0x79, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x34, 0x0a, 0x0a, 0x73, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x67, 0x61, 0x65, 0x6e, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68,
+	0x65, 0x63, 0x6b, 0x52, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22,
+	0xaf, 0x01, 0x0a, 0x0e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x43, 0x68, 0x65,
+	0x63, 0x6b, 0x12, 0x35, 0x0a, 0x0e, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f,
+	0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0d, 0x73, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x61, 0x74,
+	0x63, 0x68, 0x5f, 0x6e, 0x75, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x62, 0x61,
+	0x74, 0x63, 0x68, 0x4e, 0x75, 0x6d, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f,
+	0x73, 0x69, 0x7a, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x62, 0x61, 0x74, 0x63,
+	0x68, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x22, 0x70, 0x0a, 0x10, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x50, 0x49, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x72, 0x70, 0x69, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x04, 0x72, 0x70, 0x69, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x66, 0x72, 0x6f,
+	0x6d, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0d, 0x66, 0x72, 0x6f, 0x6d, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x74, 0x6f, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x22, 0x3d, 0x0a, 0x11, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x50, 0x49, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63,
+	0x68, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x67, 0x61, 0x65, 0x6e,
+	0x2e, 0x52, 0x50, 0x49, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68,
+	0x65, 0x73, 0x22, 0xec, 0x01, 0x0a, 0x08, 0x52, 0x50, 0x49, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12,
+	0x10, 0x0a, 0x03, 0x72, 0x70, 0x69, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x72, 0x70,
+	0x69, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x65, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03,
+	0x74, 0x65, 0x6b, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f,
+	0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x41, 0x0a, 0x1d,
+	0x72, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x1a, 0x72, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x25, 0x0a, 0x0e, 0x72, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x65, 0x72, 0x69, 0x6f,
+	0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67,
+	0x50, 0x65, 0x72, 0x69, 0x6f, 0x64, 0x12, 0x29, 0x0a, 0x07, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x45,
+	0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x66, 0x52, 0x07, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x73, 0x22, 0x4d, 0x0a, 0x09, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x66, 0x12, 0x10,
+	0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70,
+	0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e,
+	0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x41, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78,
+	0x70, 0x6f, 0x72, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a, 0x0a,
+	0x07, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x07, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x22, 0xb6, 0x01, 0x0a, 0x0a, 0x45, 0x78,
+	0x70, 0x6f, 0x72, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78,
+	0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x65, 0x78, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x33, 0x0a, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x54, 0x65, 0x6d, 0x70, 0x6f, 0x72, 0x61,
+	0x72, 0x79, 0x45, 0x78, 0x70, 0x6f, 0x73, 0x75, 0x72, 0x65, 0x4b, 0x65, 0x79, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x52, 0x06, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6b,
+	0x65, 0x79, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12,
+	0x21, 0x0a, 0x0c, 0x72, 0x65, 0x76, 0x69, 0x73, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x72, 0x65, 0x76, 0x69, 0x73, 0x65, 0x64, 0x4b, 0x65,
+	0x79, 0x73, 0x32, 0x8e, 0x02, 0x0a, 0x04, 0x47, 0x61, 0x65, 0x6e, 0x12, 0x3d, 0x0a, 0x0c, 0x44,
+	0x65, 0x63, 0x6f, 0x64, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x19, 0x2e, 0x67, 0x61,
+	0x65, 0x6e, 0x2e, 0x44, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x44, 0x65,
+	0x63, 0x6f, 0x64, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x30, 0x01, 0x12, 0x45, 0x0a, 0x0c, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x79, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x19, 0x2e, 0x67, 0x61, 0x65,
+	0x6e, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x79, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x3c, 0x0a, 0x09, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x50, 0x49, 0x73, 0x12, 0x16,
+	0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x52, 0x50, 0x49, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x50, 0x49, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x42, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x12, 0x18,
+	0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x67, 0x61, 0x65, 0x6e, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x42, 0x4b, 0x5a, 0x49, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x65, 0x78, 0x70, 0x6f, 0x73, 0x75, 0x72,
+	0x65, 0x2d, 0x6e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2d,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
+	0x70, 0x62, 0x2f, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x3b, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74,
+}
+
+var (
+	file_gaen_proto_rawDescOnce sync.Once
+	file_gaen_proto_rawDescData = file_gaen_proto_rawDesc
+)
+
+func file_gaen_proto_rawDescGZIP() []byte {
+	file_gaen_proto_rawDescOnce.Do(func() {
+		file_gaen_proto_rawDescData = protoimpl.X.CompressGZIP(file_gaen_proto_rawDescData)
+	})
+	return file_gaen_proto_rawDescData
+}
+
+var file_gaen_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_gaen_proto_goTypes = []interface{}{
+	(*DecodeExportRequest)(nil),        // 0: gaen.DecodeExportRequest
+	(*DecodedKey)(nil),                 // 1: gaen.DecodedKey
+	(*RollingProximityIdentifier)(nil), // 2: gaen.RollingProximityIdentifier
+	(*VerifyExportRequest)(nil),        // 3: gaen.VerifyExportRequest
+	(*VerifyExportResponse)(nil),       // 4: gaen.VerifyExportResponse
+	(*SignatureCheck)(nil),             // 5: gaen.SignatureCheck
+	(*MatchRPIsRequest)(nil),           // 6: gaen.MatchRPIsRequest
+	(*MatchRPIsResponse)(nil),          // 7: gaen.MatchRPIsResponse
+	(*RPIMatch)(nil),                   // 8: gaen.RPIMatch
+	(*ExportRef)(nil),                  // 9: gaen.ExportRef
+	(*ListExportsRequest)(nil),         // 10: gaen.ListExportsRequest
+	(*ListExportsResponse)(nil),        // 11: gaen.ListExportsResponse
+	(*ExportInfo)(nil),                 // 12: gaen.ExportInfo
+	(*TemporaryExposureKey)(nil),       // 13: TemporaryExposureKey
+	(*SignatureInfo)(nil),              // 14: SignatureInfo
+	(*TemporaryExposureKeyExport)(nil), // 15: TemporaryExposureKeyExport
+}
+var file_gaen_proto_depIdxs = []int32{
+	13, // 0: gaen.DecodedKey.key:type_name -> TemporaryExposureKey
+	2,  // 1: gaen.DecodedKey.rpis:type_name -> gaen.RollingProximityIdentifier
+	5,  // 2: gaen.VerifyExportResponse.signatures:type_name -> gaen.SignatureCheck
+	14, // 3: gaen.SignatureCheck.signature_info:type_name -> SignatureInfo
+	8,  // 4: gaen.MatchRPIsResponse.matches:type_name -> gaen.RPIMatch
+	9,  // 5: gaen.RPIMatch.sources:type_name -> gaen.ExportRef
+	12, // 6: gaen.ListExportsResponse.exports:type_name -> gaen.ExportInfo
+	15, // 7: gaen.ExportInfo.header:type_name -> TemporaryExposureKeyExport
+	0,  // 8: gaen.Gaen.DecodeExport:input_type -> gaen.DecodeExportRequest
+	3,  // 9: gaen.Gaen.VerifyExport:input_type -> gaen.VerifyExportRequest
+	6,  // 10: gaen.Gaen.MatchRPIs:input_type -> gaen.MatchRPIsRequest
+	10, // 11: gaen.Gaen.ListExports:input_type -> gaen.ListExportsRequest
+	1,  // 12: gaen.Gaen.DecodeExport:output_type -> gaen.DecodedKey
+	4,  // 13: gaen.Gaen.VerifyExport:output_type -> gaen.VerifyExportResponse
+	7,  // 14: gaen.Gaen.MatchRPIs:output_type -> gaen.MatchRPIsResponse
+	11, // 15: gaen.Gaen.ListExports:output_type -> gaen.ListExportsResponse
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_gaen_proto_init() }
+func file_gaen_proto_init() {
+	if File_gaen_proto != nil {
+		return
+	}
+	file_internal_pb_export_export_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_gaen_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DecodeExportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DecodedKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RollingProximityIdentifier); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyExportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyExportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SignatureCheck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MatchRPIsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MatchRPIsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RPIMatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportRef); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListExportsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListExportsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_gaen_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gaen_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gaen_proto_goTypes,
+		DependencyIndexes: file_gaen_proto_depIdxs,
+		MessageInfos:      file_gaen_proto_msgTypes,
+	}.Build()
+	File_gaen_proto = out.File
+	file_gaen_proto_rawDesc = nil
+	file_gaen_proto_goTypes = nil
+	file_gaen_proto_depIdxs = nil
+}
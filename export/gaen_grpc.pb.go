@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package export
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion7
+
+// GaenClient is the client API for Gaen service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GaenClient interface {
+	// DecodeExport streams the keys of an export, with their RPIs if requested.
+	DecodeExport(ctx context.Context, in *DecodeExportRequest, opts ...grpc.CallOption) (Gaen_DecodeExportClient, error)
+	// VerifyExport verifies the signatures of an export zip.
+	VerifyExport(ctx context.Context, in *VerifyExportRequest, opts ...grpc.CallOption) (*VerifyExportResponse, error)
+	// MatchRPIs matches the observed RPIs against the exports served.
+	MatchRPIs(ctx context.Context, in *MatchRPIsRequest, opts ...grpc.CallOption) (*MatchRPIsResponse, error)
+	// ListExports lists the exports served, without their keys.
+	ListExports(ctx context.Context, in *ListExportsRequest, opts ...grpc.CallOption) (*ListExportsResponse, error)
+}
+
+type gaenClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGaenClient(cc grpc.ClientConnInterface) GaenClient {
+	return &gaenClient{cc}
+}
+
+func (c *gaenClient) DecodeExport(ctx context.Context, in *DecodeExportRequest, opts ...grpc.CallOption) (Gaen_DecodeExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Gaen_serviceDesc.Streams[0], "/gaen.Gaen/DecodeExport", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gaenDecodeExportClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Gaen_DecodeExportClient interface {
+	Recv() (*DecodedKey, error)
+	grpc.ClientStream
+}
+
+type gaenDecodeExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *gaenDecodeExportClient) Recv() (*DecodedKey, error) {
+	m := new(DecodedKey)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gaenClient) VerifyExport(ctx context.Context, in *VerifyExportRequest, opts ...grpc.CallOption) (*VerifyExportResponse, error) {
+	out := new(VerifyExportResponse)
+	err := c.cc.Invoke(ctx, "/gaen.Gaen/VerifyExport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gaenClient) MatchRPIs(ctx context.Context, in *MatchRPIsRequest, opts ...grpc.CallOption) (*MatchRPIsResponse, error) {
+	out := new(MatchRPIsResponse)
+	err := c.cc.Invoke(ctx, "/gaen.Gaen/MatchRPIs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gaenClient) ListExports(ctx context.Context, in *ListExportsRequest, opts ...grpc.CallOption) (*ListExportsResponse, error) {
+	out := new(ListExportsResponse)
+	err := c.cc.Invoke(ctx, "/gaen.Gaen/ListExports", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GaenServer is the server API for Gaen service.
+// All implementations must embed UnimplementedGaenServer
+// for forward compatibility
+type GaenServer interface {
+	// DecodeExport streams the keys of an export, with their RPIs if requested.
+	DecodeExport(*DecodeExportRequest, Gaen_DecodeExportServer) error
+	// VerifyExport verifies the signatures of an export zip.
+	VerifyExport(context.Context, *VerifyExportRequest) (*VerifyExportResponse, error)
+	// MatchRPIs matches the observed RPIs against the exports served.
+	MatchRPIs(context.Context, *MatchRPIsRequest) (*MatchRPIsResponse, error)
+	// ListExports lists the exports served, without their keys.
+	ListExports(context.Context, *ListExportsRequest) (*ListExportsResponse, error)
+	mustEmbedUnimplementedGaenServer()
+}
+
+// UnimplementedGaenServer must be embedded to have forward compatible implementations.
+type UnimplementedGaenServer struct {
+}
+
+func (UnimplementedGaenServer) DecodeExport(*DecodeExportRequest, Gaen_DecodeExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method DecodeExport not implemented")
+}
+func (UnimplementedGaenServer) VerifyExport(context.Context, *VerifyExportRequest) (*VerifyExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyExport not implemented")
+}
+func (UnimplementedGaenServer) MatchRPIs(context.Context, *MatchRPIsRequest) (*MatchRPIsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MatchRPIs not implemented")
+}
+func (UnimplementedGaenServer) ListExports(context.Context, *ListExportsRequest) (*ListExportsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExports not implemented")
+}
+func (UnimplementedGaenServer) mustEmbedUnimplementedGaenServer() {}
+
+// UnsafeGaenServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GaenServer will
+// result in compilation errors.
+type UnsafeGaenServer interface {
+	mustEmbedUnimplementedGaenServer()
+}
+
+func RegisterGaenServer(s grpc.ServiceRegistrar, srv GaenServer) {
+	s.RegisterService(&_Gaen_serviceDesc, srv)
+}
+
+func _Gaen_DecodeExport_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DecodeExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GaenServer).DecodeExport(m, &gaenDecodeExportServer{stream})
+}
+
+type Gaen_DecodeExportServer interface {
+	Send(*DecodedKey) error
+	grpc.ServerStream
+}
+
+type gaenDecodeExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *gaenDecodeExportServer) Send(m *DecodedKey) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Gaen_VerifyExport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GaenServer).VerifyExport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gaen.Gaen/VerifyExport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GaenServer).VerifyExport(ctx, req.(*VerifyExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gaen_MatchRPIs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchRPIsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GaenServer).MatchRPIs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gaen.Gaen/MatchRPIs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GaenServer).MatchRPIs(ctx, req.(*MatchRPIsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Gaen_ListExports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListExportsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GaenServer).ListExports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gaen.Gaen/ListExports",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GaenServer).ListExports(ctx, req.(*ListExportsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Gaen_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "gaen.Gaen",
+	HandlerType: (*GaenServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "VerifyExport",
+			Handler:    _Gaen_VerifyExport_Handler,
+		},
+		{
+			MethodName: "MatchRPIs",
+			Handler:    _Gaen_MatchRPIs_Handler,
+		},
+		{
+			MethodName: "ListExports",
+			Handler:    _Gaen_ListExports_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DecodeExport",
+			Handler:       _Gaen_DecodeExport_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gaen.proto",
+}
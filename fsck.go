@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FsckResult is the result of the verification of a downloaded export
+type FsckResult struct {
+	Path     string
+	Problems []string
+}
+
+// OK returns true if no problems were found
+func (r FsckResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Fsck verifies the downloaded exports in the workDir against their manifests.
+// Temporary folders left by an interrupted download are reported as well.
+func Fsck(workDir string) ([]FsckResult, error) {
+	results := make([]FsckResult, 0)
+
+	stale, err := listStaleTmpDirs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range stale {
+		results = append(results, FsckResult{
+			Path:     dir,
+			Problems: []string{"stale temporary folder from an interrupted download"},
+		})
+	}
+
+	dirs, err := ListExportDirs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		// the folders with neither an export.bin nor a manifest, like the archive of the zips, are not exports
+		if !fileExists(filepath.Join(dir.Path, ExportBinFilename)) && !fileExists(filepath.Join(dir.Path, ManifestFilename)) {
+			continue
+		}
+		results = append(results, FsckExport(dir.Path))
+	}
+
+	return results, nil
+}
+
+// FsckExport verifies the files of the export in the dir folder against its manifest
+func FsckExport(dir string) FsckResult {
+	result := FsckResult{Path: dir, Problems: make([]string, 0)}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			result.Problems = append(result.Problems, "missing "+ManifestFilename)
+		} else {
+			result.Problems = append(result.Problems, fmt.Sprintf("invalid %s: %s", ManifestFilename, err))
+		}
+		return result
+	}
+
+	if _, ok := manifest.Files[ExportBinFilename]; !ok {
+		result.Problems = append(result.Problems, fmt.Sprintf("%s not recorded in %s", ExportBinFilename, ManifestFilename))
+	}
+
+	names := make([]string, 0, len(manifest.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sum, err := sha256File(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+		if sum != manifest.Files[name] {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: checksum mismatch", name))
+		}
+	}
+
+	return result
+}
+
+// listStaleTmpDirs returns the temporary folders left in the workDir/app folders
+func listStaleTmpDirs(workDir string) ([]string, error) {
+	stale := make([]string, 0)
+
+	apps, err := ioutil.ReadDir(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range apps {
+		if !app.IsDir() {
+			continue
+		}
+
+		files, err := ioutil.ReadDir(filepath.Join(workDir, app.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files {
+			if f.IsDir() && strings.HasPrefix(f.Name(), tmpDirPrefix) {
+				stale = append(stale, filepath.Join(workDir, app.Name(), f.Name()))
+			}
+		}
+	}
+
+	return stale, nil
+}
@@ -6,6 +6,8 @@ require (
 	github.com/golang/protobuf v1.4.2
 	github.com/jmespath/go-jmespath v0.4.0
 	github.com/spf13/cobra v1.0.0
+	go.etcd.io/bbolt v1.3.5
 	golang.org/x/crypto v0.0.0-20201002170205-7f63de1d35b0
+	google.golang.org/grpc v1.33.2
 	google.golang.org/protobuf v1.25.0
 )
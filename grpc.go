@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"gaen/export"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPCService implements the Gaen gRPC service, serving the exports, index and public keys of the APIServer
+type GRPCService struct {
+	export.UnimplementedGaenServer
+	API *APIServer
+}
+
+// DecodeExport streams the keys of the export, with their RPIs if requested
+func (s *GRPCService) DecodeExport(req *export.DecodeExportRequest, stream export.Gaen_DecodeExportServer) error {
+	bin, _, err := readExportData(req.GetExport())
+	if err != nil {
+		return grpcError(err)
+	}
+	exp, err := UnmarshalExport(bin)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	rpis := RPIsNone
+	if req.GetRpis() {
+		rpis = RPIsEager
+	}
+	it, err := NewKeyIterator(exp, rpis)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	// the iterator decodes the keys in the same order of the export
+	for i := 0; it.Next(); i++ {
+		tek := it.Key()
+		key := &export.DecodedKey{
+			Key:  exp.Keys[i],
+			Rpis: make([]*export.RollingProximityIdentifier, 0, len(tek.RPIs)),
+		}
+		for _, rpi := range tek.RPIs {
+			key.Rpis = append(key.Rpis, &export.RollingProximityIdentifier{
+				Rpi:            rpi.ID,
+				IntervalNumber: proto.Int32(int32(rpi.IntervalNumber)),
+			})
+		}
+		if err := stream.Send(key); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
+
+// VerifyExport verifies the signatures of the export zip
+func (s *GRPCService) VerifyExport(ctx context.Context, req *export.VerifyExportRequest) (*export.VerifyExportResponse, error) {
+	bin, sig, err := readExportData(req.GetExport())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	valid, checks, err := s.API.verifyExport(bin, sig)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &export.VerifyExportResponse{
+		Valid:      proto.Bool(valid),
+		Signatures: make([]*export.SignatureCheck, 0, len(checks)),
+	}
+	for _, c := range checks {
+		check := &export.SignatureCheck{
+			SignatureInfo: &export.SignatureInfo{
+				VerificationKeyId:      proto.String(c.KeyID),
+				VerificationKeyVersion: proto.String(c.KeyVersion),
+				SignatureAlgorithm:     proto.String(c.Algorithm),
+			},
+			BatchNum:  proto.Int32(c.BatchNum),
+			BatchSize: proto.Int32(c.BatchSize),
+			Valid:     proto.Bool(c.Valid),
+		}
+		if c.Error != "" {
+			check.Error = proto.String(c.Error)
+		}
+		resp.Signatures = append(resp.Signatures, check)
+	}
+	return resp, nil
+}
+
+// MatchRPIs matches the RPIs against the index, or the exports in the time window
+func (s *GRPCService) MatchRPIs(ctx context.Context, req *export.MatchRPIsRequest) (*export.MatchRPIsResponse, error) {
+	if len(req.Rpis) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no rpis")
+	}
+
+	rpis := make([]ID, 0, len(req.Rpis))
+	for _, rpi := range req.Rpis {
+		if len(rpi) != 16 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid rpi of %d bytes", len(rpi))
+		}
+		rpis = append(rpis, ID(rpi))
+	}
+
+	var from, to time.Time
+	if req.GetFromTimestamp() > 0 {
+		from = time.Unix(req.GetFromTimestamp(), 0)
+	}
+	if req.GetToTimestamp() > 0 {
+		to = time.Unix(req.GetToTimestamp(), 0)
+	}
+
+	matches, err := s.API.matchRPIs(rpis, NewWindow(from, to))
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &export.MatchRPIsResponse{Matches: make([]*export.RPIMatch, 0, len(matches))}
+	for _, m := range matches {
+		match := &export.RPIMatch{
+			Rpi:                        m.RPI,
+			Tek:                        m.TEK,
+			IntervalNumber:             proto.Int32(int32(m.IntervalNumber)),
+			RollingStartIntervalNumber: proto.Int32(int32(m.RollingStartIntervalNumber)),
+			RollingPeriod:              proto.Int32(int32(m.RollingPeriod)),
+			Sources:                    make([]*export.ExportRef, 0, len(m.Sources)),
+		}
+		for _, src := range m.Sources {
+			match.Sources = append(match.Sources, &export.ExportRef{
+				App:    proto.String(src.App),
+				Export: proto.String(src.Export),
+				Region: proto.String(src.Region),
+			})
+		}
+		resp.Matches = append(resp.Matches, match)
+	}
+	return resp, nil
+}
+
+// ListExports lists the exports, without their keys
+func (s *GRPCService) ListExports(ctx context.Context, req *export.ListExportsRequest) (*export.ListExportsResponse, error) {
+	files, err := ExportFiles(s.API.Exports)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &export.ListExportsResponse{Exports: make([]*export.ExportInfo, 0, len(files))}
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "%s: %s", file.Path, err)
+		}
+
+		info := &export.ExportInfo{
+			App:         proto.String(file.App),
+			Export:      proto.String(file.Export),
+			Path:        proto.String(file.Path),
+			Keys:        proto.Int32(int32(len(exp.Keys))),
+			RevisedKeys: proto.Int32(int32(len(exp.RevisedKeys))),
+		}
+		exp.Keys = nil
+		exp.RevisedKeys = nil
+		info.Header = exp
+
+		resp.Exports = append(resp.Exports, info)
+	}
+	return resp, nil
+}
+
+// grpcError converts an error of the APIServer to a gRPC status error
+func grpcError(err error) error {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch apiErr.status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusRequestEntityTooLarge:
+		code = codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		code = codes.FailedPrecondition
+	}
+	return status.Error(code, apiErr.Error())
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"gaen/export"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// rpisBucket maps an RPI to its TEK and interval number
+	rpisBucket = []byte("rpis")
+	// teksBucket maps a TEK to its rolling interval and the exports where it was found
+	teksBucket = []byte("teks")
+	// exportsBucket records the exports already indexed
+	exportsBucket = []byte("exports")
+)
+
+// indexedExport is the value stored in the exportsBucket
+type indexedExport struct {
+	IndexedAt time.Time
+	Keys      int
+	// SHA256 is the checksum of the indexed export.bin
+	SHA256 string
+	// TEKs are the keys of the export, used to remove them when the export changes
+	TEKs []ID
+}
+
+// RPIIndex is an on-disk index of the RPIs derived from the downloaded exports
+type RPIIndex struct {
+	db *bolt.DB
+}
+
+// ExportRef references an export where a TEK was published
+type ExportRef struct {
+	App    string
+	Export string
+	Region string `json:",omitempty"`
+}
+
+// indexedTEK is the value stored in the teksBucket
+type indexedTEK struct {
+	RollingStartIntervalNumber int
+	RollingPeriod              int
+	Sources                    []ExportRef
+}
+
+// RPIMatch is the result of a lookup in the RPIIndex
+type RPIMatch struct {
+	RPI                        ID
+	TEK                        ID
+	IntervalNumber             int
+	Interval                   time.Time
+	RollingStartIntervalNumber int
+	RollingPeriod              int
+	Sources                    []ExportRef
+}
+
+// FormattedRPIMatch is an RPIMatch with the IDs in an IDFormat
+type FormattedRPIMatch struct {
+	RPI                        FormattedID
+	TEK                        FormattedID
+	IntervalNumber             int
+	Interval                   time.Time
+	RollingStartIntervalNumber int
+	RollingPeriod              int
+	Sources                    []ExportRef
+}
+
+// FormatMatches returns the matches with the IDs in the format
+func FormatMatches(matches []*RPIMatch, format IDFormat) []*FormattedRPIMatch {
+	formatted := make([]*FormattedRPIMatch, 0, len(matches))
+	for _, m := range matches {
+		formatted = append(formatted, &FormattedRPIMatch{
+			RPI:                        format.ID(m.RPI),
+			TEK:                        format.ID(m.TEK),
+			IntervalNumber:             m.IntervalNumber,
+			Interval:                   m.Interval,
+			RollingStartIntervalNumber: m.RollingStartIntervalNumber,
+			RollingPeriod:              m.RollingPeriod,
+			Sources:                    m.Sources,
+		})
+	}
+	return formatted
+}
+
+// OpenRPIIndex opens the index in the path file, creating it if needed
+func OpenRPIIndex(path string) (*RPIIndex, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{rpisBucket, teksBucket, exportsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &RPIIndex{db: db}, nil
+}
+
+// Close closes the index
+func (idx *RPIIndex) Close() error {
+	return idx.db.Close()
+}
+
+// Build indexes the exports in the workDir that are not indexed yet, or whose export.bin
+// changed since they were indexed, returning them
+func (idx *RPIIndex) Build(workDir string) ([]ExportDir, error) {
+	dirs, err := ListExportDirs(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make([]ExportDir, 0)
+	for _, dir := range dirs {
+		// the folders without an export.bin, like the archive of the zips, are not exports
+		if !fileExists(filepath.Join(dir.Path, ExportBinFilename)) {
+			continue
+		}
+
+		sum, err := exportBinSHA256(dir.Path)
+		if err != nil {
+			return indexed, fmt.Errorf("%s: %s", dir.Path, err)
+		}
+		ok, err := idx.HasExport(dir.App, dir.Export, sum)
+		if err != nil {
+			return indexed, err
+		}
+		if ok {
+			continue
+		}
+
+		exp, err := UnmarshalExportFile(filepath.Join(dir.Path, ExportBinFilename))
+		if err != nil {
+			return indexed, fmt.Errorf("%s: %s", dir.Path, err)
+		}
+		if err := idx.AddExport(dir.App, dir.Export, sum, exp); err != nil {
+			return indexed, fmt.Errorf("%s: %s", dir.Path, err)
+		}
+		indexed = append(indexed, dir)
+	}
+
+	return indexed, nil
+}
+
+// HasExport returns true if the export of the app is already indexed with the export.bin of SHA-256 sum
+func (idx *RPIIndex) HasExport(app, exportID, sum string) (bool, error) {
+	var ok bool
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		stored, err := getIndexedExport(tx, app, exportID)
+		ok = stored != nil && stored.SHA256 == sum
+		return err
+	})
+	return ok, err
+}
+
+// AddExport derives and indexes the RPIs of all the keys of the export, whose export.bin has
+// the SHA-256 sum, in a single transaction. If the export was already indexed with a different
+// content, the keys found only in its previous content are removed with their RPIs.
+func (idx *RPIIndex) AddExport(app, exportID, sum string, exp *export.TemporaryExposureKeyExport) error {
+	it, err := NewKeyIterator(exp, RPIsEager)
+	if err != nil {
+		return err
+	}
+
+	ref := ExportRef{App: app, Export: exportID, Region: exp.GetRegion()}
+
+	return idx.db.Update(func(tx *bolt.Tx) error {
+		rpis := tx.Bucket(rpisBucket)
+		teks := tx.Bucket(teksBucket)
+
+		previous, err := getIndexedExport(tx, app, exportID)
+		if err != nil {
+			return err
+		}
+		if previous != nil {
+			if err := removeExportSources(tx, app, exportID, previous.TEKs); err != nil {
+				return err
+			}
+		}
+
+		stored := indexedExport{IndexedAt: time.Now().UTC(), SHA256: sum, TEKs: make([]ID, 0)}
+		for it.Next() {
+			tek := it.Key()
+			stored.Keys++
+			stored.TEKs = append(stored.TEKs, tek.ID)
+
+			if err := addTEKSource(teks, tek, ref); err != nil {
+				return err
+			}
+
+			for _, rpi := range tek.RPIs {
+				value := make([]byte, len(tek.ID)+4)
+				copy(value, tek.ID)
+				binary.BigEndian.PutUint32(value[len(tek.ID):], uint32(rpi.IntervalNumber))
+				if err := rpis.Put(rpi.ID, value); err != nil {
+					return err
+				}
+			}
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(exportsBucket).Put(exportKey(app, exportID), b)
+	})
+}
+
+// Lookup returns the TEK that generated the RPI, or nil if the RPI is not indexed
+func (idx *RPIIndex) Lookup(rpi ID) (*RPIMatch, error) {
+	var match *RPIMatch
+
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(rpisBucket).Get(rpi)
+		if value == nil {
+			return nil
+		}
+		if len(value) < 4 {
+			return fmt.Errorf("corrupted index entry for rpi %s", rpi)
+		}
+
+		tekID := make(ID, len(value)-4)
+		copy(tekID, value)
+		interval := int(binary.BigEndian.Uint32(value[len(tekID):]))
+
+		var tek indexedTEK
+		if b := tx.Bucket(teksBucket).Get(tekID); b != nil {
+			if err := json.Unmarshal(b, &tek); err != nil {
+				return err
+			}
+		}
+
+		match = &RPIMatch{
+			RPI:                        rpi,
+			TEK:                        tekID,
+			IntervalNumber:             interval,
+			Interval:                   IntervalTime(interval),
+			RollingStartIntervalNumber: tek.RollingStartIntervalNumber,
+			RollingPeriod:              tek.RollingPeriod,
+			Sources:                    tek.Sources,
+		}
+		return nil
+	})
+
+	return match, err
+}
+
+// addTEKSource records the export where the TEK was found
+func addTEKSource(teks *bolt.Bucket, tek *TemporaryExposureKey, ref ExportRef) error {
+	stored := indexedTEK{
+		RollingStartIntervalNumber: tek.RollingStartIntervalNumber,
+		RollingPeriod:              tek.RollingPeriod,
+		Sources:                    make([]ExportRef, 0, 1),
+	}
+
+	if b := teks.Get(tek.ID); b != nil {
+		if err := json.Unmarshal(b, &stored); err != nil {
+			return err
+		}
+		// a key can be published again with a longer rolling period
+		if tek.RollingPeriod > stored.RollingPeriod {
+			stored.RollingStartIntervalNumber = tek.RollingStartIntervalNumber
+			stored.RollingPeriod = tek.RollingPeriod
+		}
+	}
+
+	for _, source := range stored.Sources {
+		if source == ref {
+			return nil
+		}
+	}
+	stored.Sources = append(stored.Sources, ref)
+
+	b, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return teks.Put(tek.ID, b)
+}
+
+// getIndexedExport returns the record of the indexed export, or nil if the export is not indexed
+func getIndexedExport(tx *bolt.Tx, app, exportID string) (*indexedExport, error) {
+	b := tx.Bucket(exportsBucket).Get(exportKey(app, exportID))
+	if b == nil {
+		return nil, nil
+	}
+	stored := &indexedExport{}
+	if err := json.Unmarshal(b, stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// removeExportSources removes the export from the sources of its keys. The keys left without
+// sources are removed, with the RPIs derived from them.
+func removeExportSources(tx *bolt.Tx, app, exportID string, tekIDs []ID) error {
+	rpis := tx.Bucket(rpisBucket)
+	teks := tx.Bucket(teksBucket)
+
+	for _, tekID := range tekIDs {
+		b := teks.Get(tekID)
+		if b == nil {
+			continue
+		}
+		var stored indexedTEK
+		if err := json.Unmarshal(b, &stored); err != nil {
+			return err
+		}
+
+		sources := make([]ExportRef, 0, len(stored.Sources))
+		for _, source := range stored.Sources {
+			if source.App != app || source.Export != exportID {
+				sources = append(sources, source)
+			}
+		}
+		if len(sources) > 0 {
+			stored.Sources = sources
+			b, err := json.Marshal(stored)
+			if err != nil {
+				return err
+			}
+			if err := teks.Put(tekID, b); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tek := NewTemporaryExposureKey(tekID, stored.RollingStartIntervalNumber, stored.RollingPeriod)
+		if err := DecodeTEK(tek); err != nil {
+			return err
+		}
+		for _, rpi := range tek.RPIs {
+			// an RPI is removed only if it still points to this key
+			if value := rpis.Get(rpi.ID); value != nil && bytes.HasPrefix(value, tekID) {
+				if err := rpis.Delete(rpi.ID); err != nil {
+					return err
+				}
+			}
+		}
+		if err := teks.Delete(tekID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportBinSHA256 returns the SHA-256 of the export.bin in the dir folder, read from
+// its manifest if any
+func exportBinSHA256(dir string) (string, error) {
+	if m, err := ReadManifest(dir); err == nil && m.Files[ExportBinFilename] != "" {
+		return m.Files[ExportBinFilename], nil
+	}
+	return sha256File(filepath.Join(dir, ExportBinFilename))
+}
+
+// exportKey returns the key of the export in the exportsBucket
+func exportKey(app, exportID string) []byte {
+	return []byte(app + "/" + exportID)
+}
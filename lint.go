@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gaen/export"
+	"io"
+	"regexp"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Severities of the lint findings
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// Rules checked by the linter
+const (
+	LintRuleHeader         = "header"
+	LintRuleProto          = "proto"
+	LintRuleTimestamps     = "timestamps"
+	LintRuleRegion         = "region"
+	LintRuleBatch          = "batch"
+	LintRuleSignatureInfos = "signature-infos"
+	LintRuleSignatures     = "signatures"
+	LintRuleKeyOrder       = "key-order"
+	LintRuleKeyData        = "key-data"
+	LintRuleRollingPeriod  = "rolling-period"
+	LintRuleStartInterval  = "start-interval"
+	LintRuleDuplicateKey   = "duplicate-key"
+	LintRuleRiskLevel      = "transmission-risk-level"
+	LintRuleReportType     = "report-type"
+	LintRuleDaysSinceOnset = "days-since-onset"
+)
+
+// maxSignatures is the maximum number of signatures of an export
+const maxSignatures = 10
+
+// signatureInfoValue matches the allowed values of the verification key id and version
+var signatureInfoValue = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// LintFinding is a violation of the export file format
+type LintFinding struct {
+	File     string
+	Rule     string
+	Severity string
+	// Key is the index of the key in the keys, or in the revised keys if Revised is true
+	Key     *int `json:",omitempty"`
+	Revised bool `json:",omitempty"`
+	Message string
+}
+
+// String returns the finding as a single line of text
+func (f LintFinding) String() string {
+	where := f.File
+	if f.Key != nil {
+		list := "keys"
+		if f.Revised {
+			list = "revised_keys"
+		}
+		where += fmt.Sprintf(" %s[%d]", list, *f.Key)
+	}
+	return fmt.Sprintf("%s: %s %s: %s", where, f.Severity, f.Rule, f.Message)
+}
+
+// linter collects the findings of the export files
+type linter struct {
+	findings []LintFinding
+}
+
+// LintExports checks the export files against the rules of the export file format
+func LintExports(files []ExportFile) ([]LintFinding, error) {
+	l := &linter{findings: make([]LintFinding, 0)}
+	batches := make(map[string][]lintBatch)
+	sets := make([]string, 0)
+
+	for _, file := range files {
+		bin, sig, err := ReadExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+
+		exp := l.lintExport(file.Path, bin, sig)
+		if exp == nil {
+			continue
+		}
+
+		set := fmt.Sprintf("%s-%d-%d-%s", file.App, exp.GetStartTimestamp(), exp.GetEndTimestamp(), exp.GetRegion())
+		if _, ok := batches[set]; !ok {
+			sets = append(sets, set)
+		}
+		batches[set] = append(batches[set], lintBatch{file: file.Path, num: exp.GetBatchNum(), size: exp.GetBatchSize()})
+	}
+
+	for _, set := range sets {
+		l.lintBatches(batches[set])
+	}
+	return l.findings, nil
+}
+
+// HasErrors returns true if any of the findings is an error
+func HasErrors(findings []LintFinding) bool {
+	for _, f := range findings {
+		if f.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteLintFindings writes the findings in the text, json or ndjson format
+func WriteLintFindings(w io.Writer, findings []LintFinding, format string) error {
+	switch format {
+	case "text":
+		for _, f := range findings {
+			if _, err := fmt.Fprintln(w, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputJSON:
+		b, err := json.MarshalIndent(findings, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case OutputNDJSON:
+		enc := json.NewEncoder(w)
+		for _, f := range findings {
+			if err := enc.Encode(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown output [%s]", format)
+}
+
+// add records a finding
+func (l *linter) add(file, rule, severity, format string, args ...interface{}) {
+	l.findings = append(l.findings, LintFinding{File: file, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// addKey records a finding of a key
+func (l *linter) addKey(file string, key int, revised bool, rule, severity, format string, args ...interface{}) {
+	l.findings = append(l.findings, LintFinding{File: file, Rule: rule, Severity: severity, Key: &key, Revised: revised, Message: fmt.Sprintf(format, args...)})
+}
+
+// lintExport checks a single export, returning it if it can be parsed
+func (l *linter) lintExport(file string, bin, sig []byte) *export.TemporaryExposureKeyExport {
+	if !bytes.HasPrefix(bin, []byte(ExportHeader)) {
+		n := len(ExportHeader)
+		if len(bin) < n {
+			n = len(bin)
+		}
+		l.add(file, LintRuleHeader, LintError, "header is %q, expected %q", bin[:n], ExportHeader)
+		return nil
+	}
+
+	exp := &export.TemporaryExposureKeyExport{}
+	if err := proto.Unmarshal(bin[len(ExportHeader):], exp); err != nil {
+		l.add(file, LintRuleProto, LintError, "cannot unmarshal the export: %s", err)
+		return nil
+	}
+
+	switch {
+	case exp.StartTimestamp == nil || exp.EndTimestamp == nil:
+		l.add(file, LintRuleTimestamps, LintError, "start_timestamp and end_timestamp must be set")
+	case exp.GetStartTimestamp() >= exp.GetEndTimestamp():
+		l.add(file, LintRuleTimestamps, LintError, "start_timestamp %d is not before end_timestamp %d", exp.GetStartTimestamp(), exp.GetEndTimestamp())
+	}
+
+	if exp.GetRegion() == "" {
+		l.add(file, LintRuleRegion, LintWarning, "region is not set")
+	}
+
+	switch {
+	case exp.BatchNum == nil || exp.BatchSize == nil:
+		l.add(file, LintRuleBatch, LintError, "batch_num and batch_size must be set")
+	case exp.GetBatchSize() < 1 || exp.GetBatchNum() < 1 || exp.GetBatchNum() > exp.GetBatchSize():
+		l.add(file, LintRuleBatch, LintError, "batch %d of %d is not valid", exp.GetBatchNum(), exp.GetBatchSize())
+	}
+
+	l.lintSignatureInfos(file, exp)
+	l.lintSignatures(file, exp, sig)
+	l.lintKeys(file, exp.Keys, false)
+	l.lintKeys(file, exp.RevisedKeys, true)
+
+	return exp
+}
+
+// lintSignatureInfos checks the signature infos of the export
+func (l *linter) lintSignatureInfos(file string, exp *export.TemporaryExposureKeyExport) {
+	if len(exp.SignatureInfos) == 0 {
+		l.add(file, LintRuleSignatureInfos, LintError, "signature_infos is empty")
+	}
+	if len(exp.SignatureInfos) > maxSignatures {
+		l.add(file, LintRuleSignatureInfos, LintError, "%d signature_infos, more than %d", len(exp.SignatureInfos), maxSignatures)
+	}
+
+	for i, si := range exp.SignatureInfos {
+		if si.GetSignatureAlgorithm() != ECDSAWithSHA256 {
+			l.add(file, LintRuleSignatureInfos, LintError, "signature_infos[%d]: algorithm %q is not %s (ECDSA with SHA-256)", i, si.GetSignatureAlgorithm(), ECDSAWithSHA256)
+		}
+		if !signatureInfoValue.MatchString(si.GetVerificationKeyId()) {
+			l.add(file, LintRuleSignatureInfos, LintError, "signature_infos[%d]: verification_key_id %q must match %s", i, si.GetVerificationKeyId(), signatureInfoValue)
+		}
+		if !signatureInfoValue.MatchString(si.GetVerificationKeyVersion()) {
+			l.add(file, LintRuleSignatureInfos, LintError, "signature_infos[%d]: verification_key_version %q must match %s", i, si.GetVerificationKeyVersion(), signatureInfoValue)
+		}
+	}
+}
+
+// lintSignatures checks that the signatures of the export.sig file match the export
+func (l *linter) lintSignatures(file string, exp *export.TemporaryExposureKeyExport, sig []byte) {
+	if sig == nil {
+		l.add(file, LintRuleSignatures, LintWarning, "%s not found", ExportSigFilename)
+		return
+	}
+
+	list := &export.TEKSignatureList{}
+	if err := proto.Unmarshal(sig, list); err != nil {
+		l.add(file, LintRuleSignatures, LintError, "cannot unmarshal the %s: %s", ExportSigFilename, err)
+		return
+	}
+
+	if len(list.Signatures) == 0 {
+		l.add(file, LintRuleSignatures, LintError, "%s has no signatures", ExportSigFilename)
+	}
+	if len(list.Signatures) > maxSignatures {
+		l.add(file, LintRuleSignatures, LintError, "%d signatures, more than %d", len(list.Signatures), maxSignatures)
+	}
+
+	for i, s := range list.Signatures {
+		if s.GetBatchNum() != exp.GetBatchNum() || s.GetBatchSize() != exp.GetBatchSize() {
+			l.add(file, LintRuleSignatures, LintError, "signatures[%d]: batch %d of %d, the export is batch %d of %d",
+				i, s.GetBatchNum(), s.GetBatchSize(), exp.GetBatchNum(), exp.GetBatchSize())
+		}
+		if len(s.Signature) == 0 {
+			l.add(file, LintRuleSignatures, LintError, "signatures[%d]: signature is empty", i)
+		}
+
+		found := false
+		for _, si := range exp.SignatureInfos {
+			if proto.Equal(si, s.SignatureInfo) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			l.add(file, LintRuleSignatures, LintError, "signatures[%d]: signature_info not in the signature_infos of the export", i)
+		}
+	}
+}
+
+// lintKeys checks the keys, or the revised keys, of the export
+func (l *linter) lintKeys(file string, keys []*export.TemporaryExposureKey, revised bool) {
+	seen := make(map[string]int)
+
+	for i, key := range keys {
+		if len(key.KeyData) != 16 {
+			l.addKey(file, i, revised, LintRuleKeyData, LintError, "key_data is %d bytes, expected 16", len(key.KeyData))
+		}
+		if first, ok := seen[string(key.KeyData)]; ok {
+			l.addKey(file, i, revised, LintRuleDuplicateKey, LintError, "key_data already in position %d", first)
+		} else {
+			seen[string(key.KeyData)] = i
+		}
+
+		if key.RollingStartIntervalNumber == nil {
+			l.addKey(file, i, revised, LintRuleStartInterval, LintError, "rolling_start_interval_number is not set")
+		} else if key.GetRollingStartIntervalNumber()%144 != 0 {
+			l.addKey(file, i, revised, LintRuleStartInterval, LintWarning, "rolling_start_interval_number %d is not aligned to the start of a day", key.GetRollingStartIntervalNumber())
+		}
+		if rp := key.GetRollingPeriod(); rp < 1 || rp > 144 {
+			l.addKey(file, i, revised, LintRuleRollingPeriod, LintError, "rolling_period %d is not in 1..144", rp)
+		}
+
+		if key.TransmissionRiskLevel != nil {
+			if trl := key.GetTransmissionRiskLevel(); trl < 0 || trl > 8 {
+				l.addKey(file, i, revised, LintRuleRiskLevel, LintError, "transmission_risk_level %d is not in 0..8", trl)
+			}
+		}
+		if key.DaysSinceOnsetOfSymptoms != nil {
+			if dsos := key.GetDaysSinceOnsetOfSymptoms(); dsos < -14 || dsos > 14 {
+				l.addKey(file, i, revised, LintRuleDaysSinceOnset, LintError, "days_since_onset_of_symptoms %d is not in -14..14", dsos)
+			}
+		}
+		if key.ReportType != nil {
+			switch rt := key.GetReportType(); {
+			case rt == export.TemporaryExposureKey_UNKNOWN:
+				l.addKey(file, i, revised, LintRuleReportType, LintError, "report_type %s must not be published", rt)
+			case rt == export.TemporaryExposureKey_REVOKED && !revised:
+				l.addKey(file, i, revised, LintRuleReportType, LintError, "report_type %s is allowed only in the revised_keys", rt)
+			}
+		}
+	}
+
+	if sortedByInterval(keys) {
+		list := "keys"
+		if revised {
+			list = "revised_keys"
+		}
+		l.add(file, LintRuleKeyOrder, LintWarning, "the %s are ordered by rolling_start_interval_number, they should be sorted by key_data or shuffled", list)
+	}
+}
+
+// sortedByInterval returns true if the keys, not sorted by key data, are ordered
+// by rolling start interval number spanning more than one interval
+func sortedByInterval(keys []*export.TemporaryExposureKey) bool {
+	if len(keys) < 3 {
+		return false
+	}
+	byKeyData := sort.SliceIsSorted(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i].KeyData, keys[j].KeyData) < 0
+	})
+	if byKeyData {
+		return false
+	}
+
+	less := func(i, j int) bool {
+		return keys[i].GetRollingStartIntervalNumber() < keys[j].GetRollingStartIntervalNumber()
+	}
+	greater := func(i, j int) bool { return less(j, i) }
+	first, last := keys[0].GetRollingStartIntervalNumber(), keys[len(keys)-1].GetRollingStartIntervalNumber()
+	return first != last && (sort.SliceIsSorted(keys, less) || sort.SliceIsSorted(keys, greater))
+}
+
+// lintBatch is the batch of an export of a set
+type lintBatch struct {
+	file string
+	num  int32
+	size int32
+}
+
+// lintBatches checks that the exports of a set, with the same app, timestamps and region,
+// have the same batch size and every batch number once
+func (l *linter) lintBatches(batches []lintBatch) {
+	size := batches[0].size
+	nums := make(map[int32]string)
+
+	for _, b := range batches {
+		if b.size != size {
+			l.add(b.file, LintRuleBatch, LintError, "batch_size %d, other exports of the set have batch_size %d (%s)", b.size, size, batches[0].file)
+			continue
+		}
+		if other, ok := nums[b.num]; ok {
+			l.add(b.file, LintRuleBatch, LintError, "batch_num %d already used by %s", b.num, other)
+			continue
+		}
+		nums[b.num] = b.file
+	}
+
+	// a missing batch is reported only when more than one export of the set is linted
+	if len(batches) < 2 {
+		return
+	}
+	if missing := int(size) - len(nums); missing > 0 {
+		l.add(batches[0].file, LintRuleBatch, LintError, "%d of the %d batches of the set are missing", missing, size)
+	}
+}
@@ -1,11 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"gaen/efgs"
+	"gaen/export"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
-	"github.com/jmespath/go-jmespath"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
 )
 
 var version = "0.0.0-dev"
@@ -24,48 +39,1069 @@ var versionCmd = &cobra.Command{
 }
 
 var query string
+var output string
+var rows string
+var idFormatFlag string
+var tz string
+var rpis string
+var noRPIs bool
+var padding bool
+var input string
 
 var decodeCmd = &cobra.Command{
 	Use:   "decode",
 	Short: "Decode a TEK export binary file",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		teks, err := DecodeFromFile(args[0])
+		idFormat, err := ParseIDFormat(idFormatFlag)
 		if err != nil {
 			return err
 		}
+		if err := SetTimeLocation(tz); err != nil {
+			return err
+		}
+
+		if output == OutputPrototext {
+			if cmd.Flags().Changed("id-format") {
+				return fmt.Errorf("--id-format is not supported with the %s output, that prints the raw bytes", OutputPrototext)
+			}
+			export, batch, err := ReadExportInput(args[0], input)
+			if err != nil {
+				return err
+			}
+			var message proto.Message = export
+			if batch != nil {
+				message = batch
+			}
+			b, err := prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(message)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(b)
+			return err
+		}
+
+		outputOpts := OutputOptions{Format: output, Rows: rows, Query: query, Padding: padding, Origins: input == InputEFGS, IDFormat: idFormat}
+		w, err := NewKeyWriter(os.Stdout, outputOpts)
+		if err != nil {
+			return err
+		}
+
+		if noRPIs {
+			rpis = RPIsNone
+		}
+		if rpis == RPIsNone && rows == RowsRPI {
+			return fmt.Errorf("cannot write a row per RPI without deriving the RPIs")
+		}
+		// when every key needs its RPIs they are derived in parallel batches
+		if rpis == RPIsLazy && outputOpts.NeedsRPIs() {
+			rpis = RPIsEager
+		}
+
+		export, batch, err := ReadExportInput(args[0], input)
+		if err != nil {
+			return err
+		}
+		origins := make(map[string]*efgs.DiagnosisKey)
+		if batch != nil {
+			for _, key := range batch.Keys {
+				origins[string(key.GetKeyData())] = key
+			}
+		}
+
+		var report *PaddingReport
+		if padding {
+			report = detectPadding([]paddingExport{{name: args[0], exp: export}})
+		}
+
+		it, err := NewKeyIterator(export, rpis)
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			tek := it.Key()
+			if report != nil {
+				tek.PaddingScore = report.Score(tek.ID)
+			}
+			if key, ok := origins[string(tek.ID)]; ok {
+				tek.Origin = key.GetOrigin()
+				tek.VisitedCountries = key.GetVisitedCountries()
+			}
+			if err := w.Write(tek); err != nil {
+				return err
+			}
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+		return w.Close()
+	},
+}
+
+var downloadOpts = DefaultDownloadOptions()
+
+var downloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Download a TEK export binary file",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return Download("out", args[0], downloadOpts)
+	},
+}
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck [dir]",
+	Short: "Verify the downloaded exports against their manifests",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir := "out"
+		if len(args) > 0 {
+			workDir = args[0]
+		}
+
+		results, err := Fsck(workDir)
+		if err != nil {
+			return err
+		}
+
+		return printFsckResults(results, "folders")
+	},
+}
+
+var archiveDir string
+var archiveAt string
+var archiveApp string
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Inspect the archive of the original export zips",
+}
+
+var archiveListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the export zips recorded in the archive",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		archive := &Archive{Dir: archiveDir}
+
+		var entries []ArchiveEntry
+		var err error
+		if archiveAt == "" {
+			entries, err = archive.Entries()
+		} else {
+			var at time.Time
+			if at, err = parseTime(archiveAt); err != nil {
+				return err
+			}
+			entries, err = archive.PublishedAt(at)
+		}
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FETCHED AT\tAPP\tEXPORT\tSIZE\tSHA256")
+		for _, e := range entries {
+			if archiveApp != "" && e.App != archiveApp {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", e.FetchedAt.Format(time.RFC3339), e.App, e.Export, e.Size, e.SHA256)
+		}
+		return w.Flush()
+	},
+}
+
+var archiveVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify the blobs of the archive against their SHA-256",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		results, err := (&Archive{Dir: archiveDir}).Verify()
+		if err != nil {
+			return err
+		}
+
+		return printFsckResults(results, "blobs")
+	},
+}
+
+var indexDB string
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the on-disk index of the RPIs of the downloaded exports",
+}
+
+var indexBuildCmd = &cobra.Command{
+	Use:   "build [dir]",
+	Short: "Index the RPIs of the downloaded exports not indexed yet",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir := "out"
+		if len(args) > 0 {
+			workDir = args[0]
+		}
+
+		idx, err := OpenRPIIndex(indexDB)
+		if err != nil {
+			return err
+		}
+		defer idx.Close()
+
+		indexed, err := idx.Build(workDir)
+		for _, dir := range indexed {
+			fmt.Printf("indexed %s\n", dir.Path)
+		}
+		return err
+	},
+}
+
+var indexLookupCmd = &cobra.Command{
+	Use:   "lookup [rpi...]",
+	Short: "Lookup the TEKs of the RPIs (read from stdin, one per line, if none is specified)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+		if err := SetTimeLocation(tz); err != nil {
+			return err
+		}
+
+		rpis, err := argsOrStdin(args)
+		if err != nil {
+			return err
+		}
+
+		idx, err := OpenRPIIndex(indexDB)
+		if err != nil {
+			return err
+		}
+		defer idx.Close()
+
+		matches := make([]*RPIMatch, 0)
+		for _, s := range rpis {
+			rpi, err := idFormat.Parse(s)
+			if err != nil {
+				return err
+			}
+
+			match, err := idx.Lookup(rpi)
+			if err != nil {
+				return err
+			}
+			if match == nil {
+				fmt.Fprintf(os.Stderr, "rpi %s not found\n", s)
+				continue
+			}
+			matches = append(matches, match)
+		}
+
+		b, err := json.MarshalIndent(FormatMatches(matches, idFormat), "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+var filterFile string
+var filterFP float64
+var filterFrom string
+var filterTo string
+var filterExports []string
+
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Build and match a compact Bloom filter of the RPIs of a set of exports",
+}
+
+var filterBuildCmd = &cobra.Command{
+	Use:   "build [export.bin|dir...]",
+	Short: "Build a Bloom filter of the RPIs derived from the exports",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		window, err := parseWindow(filterFrom, filterTo)
+		if err != nil {
+			return err
+		}
+
+		files, err := ExportFiles(args)
+		if err != nil {
+			return err
+		}
+
+		filter, err := BuildRPIFilter(files, window, filterFP)
+		if err != nil {
+			return err
+		}
+
+		out, err := os.Create(filterFile)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		size, err := filter.WriteTo(out)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%d RPIs from %d exports in %s (%d bytes, %d hashes, false positive rate %g)\n",
+			filter.N, len(files), filterFile, size, filter.K, filter.FalsePositiveRate())
+		return out.Close()
+	},
+}
+
+var filterMatchCmd = &cobra.Command{
+	Use:   "match [rpi...]",
+	Short: "Match the RPIs (read from stdin, one per line, if none is specified) against a Bloom filter",
+	Long: `Match the RPIs against a Bloom filter.
+Without --exports the RPIs that may be in the filter are printed, with --exports
+they are confirmed deriving the RPIs of the exports and only the real matches are printed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+		if err := SetTimeLocation(tz); err != nil {
+			return err
+		}
+
+		filter, err := ReadBloomFilterFile(filterFile)
+		if err != nil {
+			return err
+		}
+
+		lines, err := argsOrStdin(args)
+		if err != nil {
+			return err
+		}
+		candidates := make([]ID, 0, len(lines))
+		for _, line := range lines {
+			rpi, err := idFormat.Parse(line)
+			if err != nil {
+				return err
+			}
+			candidates = append(candidates, rpi)
+		}
+
+		var out interface{}
+		if len(filterExports) == 0 {
+			maybe := make([]FormattedID, 0)
+			for _, rpi := range candidates {
+				if filter.Test(rpi) {
+					maybe = append(maybe, idFormat.ID(rpi))
+				}
+			}
+			out = maybe
+		} else {
+			files, err := ExportFiles(filterExports)
+			if err != nil {
+				return err
+			}
+			matches, err := MatchRPIs(filter, files, candidates)
+			if err != nil {
+				return err
+			}
+			out = FormatMatches(matches, idFormat)
+		}
+
+		b, err := json.MarshalIndent(out, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+var whoisAt string
+var whoisTolerance time.Duration
+var whoisExports []string
+
+var whoisCmd = &cobra.Command{
+	Use:   "whois <rpi>",
+	Short: "Find the TEK that generated an RPI, scanning the downloaded exports",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+		if err := SetTimeLocation(tz); err != nil {
+			return err
+		}
+
+		rpi, err := idFormat.Parse(args[0])
+		if err != nil {
+			return err
+		}
+
+		var at time.Time
+		if whoisAt != "" {
+			if at, err = parseTime(whoisAt); err != nil {
+				return err
+			}
+		}
+
+		files, err := ExportFiles(whoisExports)
+		if err != nil {
+			return err
+		}
+
+		matches, err := Whois(files, rpi, at, whoisTolerance)
+		if err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no TEK found for the RPI %s in %d exports", args[0], len(files))
+		}
+
+		b, err := json.MarshalIndent(FormatMatches(matches, idFormat), "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+var statsOutput string
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [export.bin|dir...]",
+	Short: "Summarize the keys of one or more exports",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := ExportFiles(args)
+		if err != nil {
+			return err
+		}
+
+		stats, err := ComputeStats(files)
+		if err != nil {
+			return err
+		}
+		if padding {
+			if stats.Padding, err = DetectPadding(files); err != nil {
+				return err
+			}
+		}
+
+		switch statsOutput {
+		case OutputTable:
+			return stats.WriteTable(os.Stdout)
+		case OutputJSON:
+			b, err := json.MarshalIndent(stats, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+		return fmt.Errorf("unknown output [%s]", statsOutput)
+	},
+}
+
+var timelineOutput string
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline [export.bin|dir...]",
+	Short: "Aggregate the unique keys per upload day and per key date across the downloaded exports",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = []string{"out"}
+		}
+
+		files, err := ExportFiles(args)
+		if err != nil {
+			return err
+		}
+
+		timeline, err := ComputeTimeline(files)
+		if err != nil {
+			return err
+		}
+
+		switch timelineOutput {
+		case OutputCSV:
+			return timeline.WriteCSV(os.Stdout)
+		case OutputJSON:
+			b, err := json.MarshalIndent(timeline, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+		return fmt.Errorf("unknown output [%s]", timelineOutput)
+	},
+}
+
+var diffOutput string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compare the keys and the metadata of two export files or two download folders",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+
+		diff, err := DiffExports(args[0], args[1], idFormat)
+		if err != nil {
+			return err
+		}
+
+		switch diffOutput {
+		case "text":
+			return diff.WriteText(os.Stdout)
+		case OutputJSON:
+			b, err := json.MarshalIndent(diff, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+		return fmt.Errorf("unknown output [%s]", diffOutput)
+	},
+}
+
+var lintOutput string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [export.bin|export.zip|dir...]",
+	Short: "Check the exports against the rules of the export file format",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := ExportFiles(args)
+		if err != nil {
+			return err
+		}
+
+		findings, err := LintExports(files)
+		if err != nil {
+			return err
+		}
+		if err := WriteLintFindings(os.Stdout, findings, lintOutput); err != nil {
+			return err
+		}
+
+		if HasErrors(findings) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("the exports do not conform to the export file format")
+		}
+		return nil
+	},
+}
+
+var mergeOut string
+var mergeFrom string
+var mergeTo string
+var mergeOpts = MergeOptions{MaxKeys: DefaultMaxKeysPerExport}
+var signingKey string
+var signingKeyID string
+var signingKeyVersion string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Build new export files",
+}
+
+var exportMergeCmd = &cobra.Command{
+	Use:   "merge [export.bin|dir...]",
+	Short: "Merge, filter and re-batch the keys of a set of exports in new export files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		window, err := parseWindow(mergeFrom, mergeTo)
+		if err != nil {
+			return err
+		}
+		mergeOpts.Window = window
+
+		if signingKey != "" {
+			if mergeOpts.Signer, err = LoadExportSigner(signingKey, signingKeyID, signingKeyVersion); err != nil {
+				return err
+			}
+		}
+
+		files, err := ExportFiles(args)
+		if err != nil {
+			return err
+		}
+
+		exports, err := MergeExports(files, mergeOpts)
+		if err != nil {
+			return err
+		}
+
+		filenames, err := WriteExports(mergeOut, exports, mergeOpts.Signer)
+		for i, filename := range filenames {
+			fmt.Printf("%s: batch %d/%d, %d keys\n", filename, i+1, len(exports), len(exports[i].Keys))
+		}
+		return err
+	},
+}
+
+var efgsToExportOut string
+var efgsFromExportOut string
+var efgsRegion string
+var efgsOrigin string
+var efgsVisited []string
+
+var efgsCmd = &cobra.Command{
+	Use:   "efgs",
+	Short: "Convert the diagnosis key batches of the European Federation Gateway Service",
+}
+
+var efgsToExportCmd = &cobra.Command{
+	Use:   "to-export <batch>",
+	Short: "Convert an EFGS DiagnosisKeyBatch to an export zip",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		batch, err := UnmarshalDiagnosisKeyBatch(in)
+		if err != nil {
+			return fmt.Errorf("%s: %s", args[0], err)
+		}
+
+		exp := BatchToExport(batch, efgsRegion)
+		if err := WriteExportZip(efgsToExportOut, exp, nil); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d keys, region %s\n", efgsToExportOut, len(exp.Keys), exp.GetRegion())
+		return nil
+	},
+}
+
+var efgsFromExportCmd = &cobra.Command{
+	Use:   "from-export <export.bin|export.zip>",
+	Short: "Convert an export to an EFGS DiagnosisKeyBatch",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exp, err := UnmarshalExportFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		batch, err := ExportToBatch(exp, efgsOrigin, efgsVisited)
+		if err != nil {
+			return err
+		}
+		b, err := proto.Marshal(batch)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(efgsFromExportOut, b, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d keys\n", efgsFromExportOut, len(batch.Keys))
+		return nil
+	},
+}
+
+var mockAddr string
+
+var serveMockCmd = &cobra.Command{
+	Use:   "serve-mock [export.bin|export.zip|dir...]",
+	Short: "Serve a set of exports emulating the Immuni, SwissCovid and index.txt backends",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = []string{"out"}
+		}
+
+		files, err := ExportFiles(args)
+		if err != nil {
+			return err
+		}
+
+		server, err := NewMockServer(files)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("serving %d exports on http://%s\n", server.Len(), mockAddr)
+		return http.ListenAndServe(mockAddr, server)
+	},
+}
+
+var apiServeAddr string
+var apiGRPCAddr string
+var apiIndex string
+var apiPublicKeys []string
+var apiServer = &APIServer{MaxBodySize: DefaultMaxBodySize, MaxMatchRPIs: DefaultMaxMatchRPIs, MaxQueryKeys: DefaultMaxQueryKeys}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the decode, verify and match operations as an HTTP API",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+		if err := SetTimeLocation(tz); err != nil {
+			return err
+		}
+
+		apiServer.IDFormat = idFormat
+		closeIndex, err := setupAPIServer()
+		if err != nil {
+			return err
+		}
+		defer closeIndex()
 
-		var out interface{}
+		fmt.Printf("serving the API on http://%s\n", apiServeAddr)
+		return http.ListenAndServe(apiServeAddr, apiServer)
+	},
+}
 
-		if query == "" {
-			out = teks
-		} else {
-			out, err = jmespath.Search(query, teks)
+var grpcServeCmd = &cobra.Command{
+	Use:   "grpc-serve",
+	Short: "Serve the decode, verify, match and list operations as a gRPC service",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		closeIndex, err := setupAPIServer()
+		if err != nil {
+			return err
+		}
+		defer closeIndex()
+
+		lis, err := net.Listen("tcp", apiGRPCAddr)
+		if err != nil {
+			return err
+		}
+
+		server := grpc.NewServer(grpc.MaxRecvMsgSize(int(apiServer.MaxBodySize)))
+		export.RegisterGaenServer(server, &GRPCService{API: apiServer})
+
+		fmt.Printf("serving the gRPC service on %s\n", apiGRPCAddr)
+		return server.Serve(lis)
+	},
+}
+
+// setupAPIServer loads the public keys and opens the index of the apiServer, returning the func to close it
+func setupAPIServer() (func(), error) {
+	for _, filename := range apiPublicKeys {
+		key, err := LoadPublicKey(filename)
+		if err != nil {
+			return nil, err
+		}
+		apiServer.PublicKeys = append(apiServer.PublicKeys, key)
+	}
+
+	if apiIndex == "" {
+		return func() {}, nil
+	}
+	idx, err := OpenRPIIndex(apiIndex)
+	if err != nil {
+		return nil, err
+	}
+	apiServer.Index = idx
+	return func() { idx.Close() }, nil
+}
+
+var publishURL string
+var publishHMACKey string
+var publishOpts PublishOptions
+
+var publishCmd = &cobra.Command{
+	Use:   "publish [keys.json]",
+	Short: "Publish the keys written by decode (read from stdin if no file is specified) to a publish endpoint",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+
+		in := os.Stdin
+		if len(args) > 0 {
+			f, err := os.Open(args[0])
 			if err != nil {
 				return err
 			}
+			defer f.Close()
+			in = f
 		}
 
-		// print something
-		b, err := json.MarshalIndent(out, "", "    ")
+		teks, err := ReadTEKs(in, idFormat)
+		if err != nil {
+			return err
+		}
+
+		if publishHMACKey != "" {
+			if publishOpts.HMACKey, err = base64.StdEncoding.DecodeString(publishHMACKey); err != nil {
+				return fmt.Errorf("invalid hmac key: %s", err)
+			}
+		}
+
+		req, err := NewPublishRequest(teks, publishOpts)
+		if err != nil {
+			return err
+		}
+		resp, err := Publish(publishURL, req)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("%+v", string(b))
+		b, err := json.MarshalIndent(resp, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
 		return nil
 	},
 }
 
-var downloadCmd = &cobra.Command{
-	Use:   "download",
-	Short: "Download a TEK export binary file",
+var tekmacHMACKey string
+var tekmacTransmissionRisk int
+
+var tekmacCmd = &cobra.Command{
+	Use:   "tekmac [keys.json]",
+	Short: "Compute the HMAC of the keys written by decode (read from stdin if no file is specified) for a verification certificate",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(tekmacHMACKey)
+		if err != nil || len(secret) == 0 {
+			return fmt.Errorf("invalid hmac key [%s]: set a base64 key", tekmacHMACKey)
+		}
+
+		in := os.Stdin
+		if len(args) > 0 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		teks, err := ReadTEKs(in, idFormat)
+		if err != nil {
+			return err
+		}
+
+		mac, err := ExposureKeyHMAC(NewPublishKeys(teks, tekmacTransmissionRisk), secret)
+		if err != nil {
+			return err
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(mac))
+		return nil
+	},
+}
+
+var verification VerificationClient
+var verifyPublishURL string
+var verifyHMACKey string
+var verifyOpts PublishOptions
+
+var verifyCodeCmd = &cobra.Command{
+	Use:   "verify-code <code> [keys.json]",
+	Short: "Exchange the verification code for a certificate and publish the keys written by decode (read from stdin if no file is specified)",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+		// the API key is read from the environment here, so it is never printed as the default of the flag
+		if verification.APIKey == "" {
+			verification.APIKey = os.Getenv("GAEN_VERIFICATION_API_KEY")
+		}
+		if verification.APIKey == "" {
+			return fmt.Errorf("the API key of the verification server is required")
+		}
+
+		in := os.Stdin
+		if len(args) > 1 {
+			f, err := os.Open(args[1])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		teks, err := ReadTEKs(in, idFormat)
+		if err != nil {
+			return err
+		}
+
+		if verifyHMACKey != "" {
+			if verifyOpts.HMACKey, err = base64.StdEncoding.DecodeString(verifyHMACKey); err != nil {
+				return fmt.Errorf("invalid hmac key: %s", err)
+			}
+		}
+
+		verified, published, err := VerifyAndPublish(&verification, args[0], teks, verifyPublishURL, verifyOpts)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(map[string]interface{}{
+			"testType":    verified.TestType,
+			"symptomDate": verified.SymptomDate,
+			"testDate":    verified.TestDate,
+			"publish":     published,
+		}, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+var watchOpts = WatchOptions{Download: DefaultDownloadOptions()}
+var watchInterval time.Duration
+var watchOnce bool
+var watchPublicKeys []string
+var watchDecode string
+var watchMatch []string
+
+var watchCmd = &cobra.Command{
+	Use:   "watch <app...>",
+	Short: "Download the new exports of the apps periodically, running the actions on them",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return Download("out", args[0])
+		idFormat, err := ParseIDFormat(idFormatFlag)
+		if err != nil {
+			return err
+		}
+		for _, app := range args {
+			if _, err := DownloaderFactory(app, ""); err != nil {
+				return err
+			}
+		}
+
+		for _, filename := range watchPublicKeys {
+			key, err := LoadPublicKey(filename)
+			if err != nil {
+				return err
+			}
+			watchOpts.PublicKeys = append(watchOpts.PublicKeys, key)
+		}
+
+		if watchDecode != "" {
+			f, err := os.OpenFile(watchDecode, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			watchOpts.Sink = f
+		}
+		watchOpts.IDFormat = idFormat
+		watchOpts.SinkOptions.IDFormat = idFormat
+
+		watchOpts.RPISets = make(map[string][]ID)
+		for _, filename := range watchMatch {
+			set, err := ReadRPISet(filename, idFormat)
+			if err != nil {
+				return err
+			}
+			watchOpts.RPISets[filename] = set
+		}
+
+		watcher := &Watcher{WorkDir: "out", Apps: args, Options: watchOpts}
+		enc := json.NewEncoder(os.Stdout)
+		onEvent := func(event *WatchEvent) { enc.Encode(event) }
+		onError := func(err error) { fmt.Fprintln(os.Stderr, err) }
+
+		if watchOnce {
+			events, errs := watcher.Poll()
+			for _, event := range events {
+				onEvent(event)
+			}
+			for _, err := range errs {
+				onError(err)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("%d of %d apps failed", len(errs), len(args))
+			}
+			return nil
+		}
+
+		stop := make(chan struct{})
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sig
+			close(stop)
+		}()
+
+		watcher.Run(watchInterval, stop, onEvent, onError)
+		return nil
 	},
 }
 
+// parseWindow returns the Window between the from and to times, that can be empty
+func parseWindow(from, to string) (Window, error) {
+	var fromTime, toTime time.Time
+	var err error
+
+	if from != "" {
+		if fromTime, err = parseTime(from); err != nil {
+			return Window{}, err
+		}
+	}
+	if to != "" {
+		if toTime, err = parseTime(to); err != nil {
+			return Window{}, err
+		}
+	}
+	return NewWindow(fromTime, toTime), nil
+}
+
+// argsOrStdin returns the args, or the non empty lines of the stdin if there are no args
+func argsOrStdin(args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// printFsckResults prints the results of a verification, returning an error if any of them failed
+func printFsckResults(results []FsckResult, what string) error {
+	failed := 0
+	for _, r := range results {
+		if r.OK() {
+			fmt.Printf("OK      %s\n", r.Path)
+			continue
+		}
+		failed++
+		for _, p := range r.Problems {
+			fmt.Printf("FAIL    %s: %s\n", r.Path, p)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s failed verification", failed, len(results), what)
+	}
+	return nil
+}
+
 func main() {
 	rootCmd.AddCommand(versionCmd)
 
@@ -73,8 +1109,444 @@ func main() {
 		&query, "query", "q", "",
 		"query",
 	)
+	decodeCmd.Flags().StringVarP(
+		&output, "output", "o", OutputJSON,
+		"output format: json, ndjson, csv, table or prototext",
+	)
+	decodeCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	decodeCmd.Flags().StringVar(
+		&tz, "tz", "UTC",
+		"time zone of the RPI intervals (e.g. UTC, Local, Europe/Rome)",
+	)
+	decodeCmd.Flags().StringVar(
+		&rpis, "rpis", RPIsLazy,
+		"derivation of the RPIs: eager, lazy (only when the output needs them) or none",
+	)
+	decodeCmd.Flags().BoolVar(
+		&noRPIs, "no-rpis", false,
+		"do not derive the RPIs (same as --rpis=none)",
+	)
+	decodeCmd.Flags().BoolVar(
+		&padding, "padding", false,
+		"score the keys with the padding (fake keys) heuristics",
+	)
+	decodeCmd.Flags().StringVar(
+		&rows, "rows", RowsTEK,
+		"write a row per TEK (tek) or per RPI (rpi)",
+	)
+	decodeCmd.Flags().StringVar(
+		&input, "input", InputExport,
+		"input file: export (an export.bin or export zip) or efgs (an EFGS DiagnosisKeyBatch)",
+	)
 
 	rootCmd.AddCommand(decodeCmd)
+
+	downloadCmd.Flags().StringVar(
+		&downloadOpts.BaseURL, "url", "",
+		"base url of the app backend (default the official one), e.g. of a gaen serve-mock",
+	)
+	downloadCmd.Flags().StringVar(
+		&downloadOpts.ArchiveDir, "archive", "",
+		"keep the original zip in the content-addressed archive in this folder",
+	)
+	downloadCmd.Flags().IntVar(
+		&downloadOpts.Limits.MaxFiles, "max-files", downloadOpts.Limits.MaxFiles,
+		"maximum number of entries in the export zip (0 for no limit)",
+	)
+	downloadCmd.Flags().Int64Var(
+		&downloadOpts.Limits.MaxFileSize, "max-file-size", downloadOpts.Limits.MaxFileSize,
+		"maximum uncompressed size in bytes of an entry of the export zip (0 for no limit)",
+	)
+	downloadCmd.Flags().Int64Var(
+		&downloadOpts.Limits.MaxTotalSize, "max-total-size", downloadOpts.Limits.MaxTotalSize,
+		"maximum uncompressed size in bytes of the export zip (0 for no limit)",
+	)
+	downloadCmd.Flags().Float64Var(
+		&downloadOpts.Limits.MaxRatio, "max-ratio", downloadOpts.Limits.MaxRatio,
+		"maximum compression ratio of an entry of the export zip (0 for no limit)",
+	)
+	downloadCmd.Flags().StringSliceVar(
+		&downloadOpts.Limits.AllowedFiles, "allow", downloadOpts.Limits.AllowedFiles,
+		"entries allowed in the export zip (empty to allow any entry)",
+	)
 	rootCmd.AddCommand(downloadCmd)
-	rootCmd.Execute()
+
+	watchCmd.Flags().StringVar(
+		&watchOpts.Download.BaseURL, "url", "",
+		"base url of the app backend (e.g. http://localhost:8080 to use serve-mock)",
+	)
+	watchCmd.Flags().StringVar(
+		&watchOpts.Download.ArchiveDir, "archive", "",
+		"archive folder where the original export zips are kept",
+	)
+	watchCmd.Flags().DurationVar(
+		&watchInterval, "interval", DefaultWatchInterval,
+		"interval between two polls of the backends",
+	)
+	watchCmd.Flags().BoolVar(
+		&watchOnce, "once", false,
+		"poll the backends once and exit",
+	)
+	watchCmd.Flags().StringSliceVar(
+		&watchPublicKeys, "public-key", nil,
+		"PEM public key to verify the signatures of the new exports (can be repeated)",
+	)
+	watchCmd.Flags().StringVar(
+		&watchDecode, "decode", "",
+		"file where the keys of the new exports are appended, as ndjson",
+	)
+	watchCmd.Flags().StringVar(
+		&watchOpts.SinkOptions.Rows, "rows", RowsTEK,
+		"write a row per TEK (tek) or per RPI (rpi)",
+	)
+	watchCmd.Flags().StringVarP(
+		&watchOpts.SinkOptions.Query, "query", "q", "",
+		"JMESPath query applied to every row of the decoded keys",
+	)
+	watchCmd.Flags().StringSliceVar(
+		&watchMatch, "match", nil,
+		"file of RPIs, one per line, matched against the new exports (can be repeated)",
+	)
+	watchCmd.Flags().StringVar(
+		&watchOpts.Webhook, "webhook", "",
+		"url the event is posted to when there are new keys, matches or errors",
+	)
+	watchCmd.Flags().StringVar(
+		&watchOpts.Command, "exec", "",
+		"shell command run with the event on its stdin when there are new keys, matches or errors",
+	)
+	watchCmd.Flags().DurationVar(
+		&watchOpts.NotifyTimeout, "notify-timeout", DefaultNotifyTimeout,
+		"timeout of the webhook and of the shell command",
+	)
+	watchCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(fsckCmd)
+
+	archiveCmd.PersistentFlags().StringVar(
+		&archiveDir, "dir", "archive",
+		"folder of the archive",
+	)
+	archiveListCmd.Flags().StringVar(
+		&archiveAt, "at", "",
+		"list only the latest zip of every export fetched at or before this time (RFC3339 or 2006-01-02)",
+	)
+	archiveListCmd.Flags().StringVar(
+		&archiveApp, "app", "",
+		"list only the zips of this app",
+	)
+	archiveCmd.AddCommand(archiveListCmd)
+	archiveCmd.AddCommand(archiveVerifyCmd)
+	rootCmd.AddCommand(archiveCmd)
+
+	indexCmd.PersistentFlags().StringVar(
+		&indexDB, "db", "index.db",
+		"file of the index",
+	)
+	indexLookupCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	indexLookupCmd.Flags().StringVar(
+		&tz, "tz", "UTC",
+		"time zone of the RPI intervals (e.g. UTC, Local, Europe/Rome)",
+	)
+	indexCmd.AddCommand(indexBuildCmd)
+	indexCmd.AddCommand(indexLookupCmd)
+	rootCmd.AddCommand(indexCmd)
+
+	filterCmd.PersistentFlags().StringVarP(
+		&filterFile, "filter", "f", "filter.bin",
+		"file of the filter",
+	)
+	filterBuildCmd.Flags().Float64Var(
+		&filterFP, "fp", 0.001,
+		"false positive rate of the filter",
+	)
+	filterBuildCmd.Flags().StringVar(
+		&filterFrom, "from", "",
+		"add only the RPIs from this time (RFC3339 or 2006-01-02)",
+	)
+	filterBuildCmd.Flags().StringVar(
+		&filterTo, "to", "",
+		"add only the RPIs before this time (RFC3339 or 2006-01-02)",
+	)
+	filterMatchCmd.Flags().StringSliceVar(
+		&filterExports, "exports", nil,
+		"export files or folders used to confirm the matches",
+	)
+	filterMatchCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	filterMatchCmd.Flags().StringVar(
+		&tz, "tz", "UTC",
+		"time zone of the RPI intervals (e.g. UTC, Local, Europe/Rome)",
+	)
+	filterCmd.AddCommand(filterBuildCmd)
+	filterCmd.AddCommand(filterMatchCmd)
+	rootCmd.AddCommand(filterCmd)
+
+	whoisCmd.Flags().StringVar(
+		&whoisAt, "at", "",
+		"time the RPI was seen (RFC3339 or 2006-01-02): only the keys valid around it are derived",
+	)
+	whoisCmd.Flags().DurationVar(
+		&whoisTolerance, "tolerance", 2*time.Hour,
+		"tolerance around the --at time, for the clock skew of the devices",
+	)
+	whoisCmd.Flags().StringSliceVar(
+		&whoisExports, "exports", []string{"out"},
+		"export files or folders to scan",
+	)
+	whoisCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	whoisCmd.Flags().StringVar(
+		&tz, "tz", "UTC",
+		"time zone of the RPI intervals (e.g. UTC, Local, Europe/Rome)",
+	)
+	rootCmd.AddCommand(whoisCmd)
+
+	statsCmd.Flags().StringVarP(
+		&statsOutput, "output", "o", OutputTable,
+		"output format: table or json",
+	)
+	statsCmd.Flags().BoolVar(
+		&padding, "padding", false,
+		"detect the padding (fake keys) with a set of heuristics",
+	)
+	rootCmd.AddCommand(statsCmd)
+
+	timelineCmd.Flags().StringVarP(
+		&timelineOutput, "output", "o", OutputCSV,
+		"output format: csv or json",
+	)
+	rootCmd.AddCommand(timelineCmd)
+
+	diffCmd.Flags().StringVarP(
+		&diffOutput, "output", "o", "text",
+		"output format: text or json",
+	)
+	diffCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	rootCmd.AddCommand(diffCmd)
+
+	lintCmd.Flags().StringVarP(
+		&lintOutput, "output", "o", "text",
+		"output format: text, json or ndjson",
+	)
+	rootCmd.AddCommand(lintCmd)
+
+	exportMergeCmd.Flags().StringVar(
+		&mergeOut, "out", "merged",
+		"folder where the merged export zips are written",
+	)
+	exportMergeCmd.Flags().StringVar(
+		&mergeFrom, "from", "",
+		"keep only the keys from this date (RFC3339 or 2006-01-02)",
+	)
+	exportMergeCmd.Flags().StringVar(
+		&mergeTo, "to", "",
+		"keep only the keys before this date (RFC3339 or 2006-01-02)",
+	)
+	exportMergeCmd.Flags().StringSliceVar(
+		&mergeOpts.ReportTypes, "report-type", nil,
+		"keep only the keys with these report types (e.g. CONFIRMED_TEST)",
+	)
+	exportMergeCmd.Flags().StringSliceVar(
+		&mergeOpts.Regions, "region", nil,
+		"merge only the exports of these regions",
+	)
+	exportMergeCmd.Flags().StringVar(
+		&mergeOpts.Region, "set-region", "",
+		"region of the merged exports (default the region shared by all the exports)",
+	)
+	exportMergeCmd.Flags().IntVar(
+		&mergeOpts.MaxKeys, "max-keys", DefaultMaxKeysPerExport,
+		"maximum number of keys in a single export file",
+	)
+	exportMergeCmd.Flags().StringVar(
+		&signingKey, "signing-key", "",
+		"PEM encoded ECDSA P-256 private key used to sign the exports",
+	)
+	exportMergeCmd.Flags().StringVar(
+		&signingKeyID, "key-id", "",
+		"verification key id of the signing key (e.g. the MCC of the country)",
+	)
+	exportMergeCmd.Flags().StringVar(
+		&signingKeyVersion, "key-version", "v1",
+		"verification key version of the signing key",
+	)
+	exportCmd.AddCommand(exportMergeCmd)
+	rootCmd.AddCommand(exportCmd)
+
+	efgsToExportCmd.Flags().StringVar(
+		&efgsToExportOut, "out", "export.zip",
+		"export zip to write",
+	)
+	efgsToExportCmd.Flags().StringVar(
+		&efgsRegion, "region", "",
+		"region of the export (default the origin of the keys, if they all have the same)",
+	)
+	efgsCmd.AddCommand(efgsToExportCmd)
+	efgsFromExportCmd.Flags().StringVar(
+		&efgsFromExportOut, "out", "batch.bin",
+		"DiagnosisKeyBatch file to write",
+	)
+	efgsFromExportCmd.Flags().StringVar(
+		&efgsOrigin, "origin", "",
+		"origin country of the keys (default the region of the export)",
+	)
+	efgsFromExportCmd.Flags().StringSliceVar(
+		&efgsVisited, "visited", nil,
+		"visited countries of the keys (e.g. DE,IT)",
+	)
+	efgsCmd.AddCommand(efgsFromExportCmd)
+	rootCmd.AddCommand(efgsCmd)
+
+	serveMockCmd.Flags().StringVar(
+		&mockAddr, "addr", "localhost:8080",
+		"address to listen on",
+	)
+	rootCmd.AddCommand(serveMockCmd)
+
+	serveCmd.Flags().StringVar(
+		&apiServeAddr, "addr", "localhost:8080",
+		"address to listen on",
+	)
+	grpcServeCmd.Flags().StringVar(
+		&apiGRPCAddr, "addr", "localhost:9090",
+		"address to listen on",
+	)
+	for _, c := range []*cobra.Command{serveCmd, grpcServeCmd} {
+		c.Flags().StringSliceVar(
+			&apiServer.Exports, "exports", []string{"out"},
+			"export files or folders the RPIs are matched against",
+		)
+		c.Flags().StringVar(
+			&apiIndex, "index", "",
+			"RPI index used to match the RPIs instead of scanning the exports",
+		)
+		c.Flags().StringSliceVar(
+			&apiPublicKeys, "public-key", nil,
+			"PEM encoded ECDSA P-256 public keys used to verify the signatures",
+		)
+		c.Flags().Int64Var(
+			&apiServer.MaxBodySize, "max-body", DefaultMaxBodySize,
+			"maximum size of the body of a request, in bytes",
+		)
+		c.Flags().IntVar(
+			&apiServer.MaxMatchRPIs, "max-rpis", DefaultMaxMatchRPIs,
+			"maximum number of RPIs of a match request",
+		)
+	}
+	serveCmd.Flags().IntVar(
+		&apiServer.MaxQueryKeys, "max-query-keys", DefaultMaxQueryKeys,
+		"maximum number of keys of a decode request with a query and the json format",
+	)
+	serveCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	serveCmd.Flags().StringVar(
+		&tz, "tz", "UTC",
+		"time zone of the RPI intervals (e.g. UTC, Local, Europe/Rome)",
+	)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(grpcServeCmd)
+
+	publishCmd.Flags().StringVar(
+		&publishURL, "url", DefaultPublishURL,
+		"url of the publish endpoint",
+	)
+	publishCmd.Flags().StringVar(
+		&publishOpts.HealthAuthorityID, "health-authority", "",
+		"health authority ID of the app (e.g. com.example.app)",
+	)
+	publishCmd.Flags().StringVar(
+		&publishOpts.VerificationPayload, "certificate", "",
+		"verification certificate of the keys",
+	)
+	publishCmd.Flags().StringVar(
+		&publishHMACKey, "hmac-key", "",
+		"base64 HMAC key of the verification certificate (default a random key)",
+	)
+	publishCmd.Flags().IntVar(
+		&publishOpts.TransmissionRisk, "transmission-risk", 0,
+		"transmission risk of the keys",
+	)
+	publishCmd.Flags().BoolVar(
+		&publishOpts.Traveler, "traveler", false,
+		"the user traveled",
+	)
+	publishCmd.Flags().StringVar(
+		&publishOpts.RevisionToken, "revision-token", "",
+		"revision token of a previous publish, to revise its keys",
+	)
+	publishCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	rootCmd.AddCommand(publishCmd)
+
+	tekmacCmd.Flags().StringVar(
+		&tekmacHMACKey, "hmac-key", "",
+		"base64 HMAC key",
+	)
+	tekmacCmd.Flags().IntVar(
+		&tekmacTransmissionRisk, "transmission-risk", 0,
+		"transmission risk of the keys, as in the publish request",
+	)
+	tekmacCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	rootCmd.AddCommand(tekmacCmd)
+
+	verifyCodeCmd.Flags().StringVar(
+		&verification.URL, "verification-url", DefaultVerificationURL,
+		"base url of the device API of the verification server",
+	)
+	verifyCodeCmd.Flags().StringVar(
+		&verification.APIKey, "api-key", "",
+		"device API key of the verification server (default $GAEN_VERIFICATION_API_KEY)",
+	)
+	verifyCodeCmd.Flags().StringVar(
+		&verifyPublishURL, "url", DefaultPublishURL,
+		"url of the publish endpoint",
+	)
+	verifyCodeCmd.Flags().StringVar(
+		&verifyOpts.HealthAuthorityID, "health-authority", "",
+		"health authority ID of the app (e.g. com.example.app)",
+	)
+	verifyCodeCmd.Flags().StringVar(
+		&verifyHMACKey, "hmac-key", "",
+		"base64 HMAC key of the verification certificate (default a random key)",
+	)
+	verifyCodeCmd.Flags().IntVar(
+		&verifyOpts.TransmissionRisk, "transmission-risk", 0,
+		"transmission risk of the keys",
+	)
+	verifyCodeCmd.Flags().BoolVar(
+		&verifyOpts.Traveler, "traveler", false,
+		"the user traveled",
+	)
+	verifyCodeCmd.Flags().StringVar(
+		&idFormatFlag, "id-format", string(IDFormatBase64),
+		"format of the IDs: base64, base64url, hex or int-array",
+	)
+	rootCmd.AddCommand(verifyCodeCmd)
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
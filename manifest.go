@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ManifestFilename is the name of the manifest written in every downloaded export folder
+	ManifestFilename = "manifest.json"
+	// ExportBinFilename is the name of the export binary file inside the export zip
+	ExportBinFilename = "export.bin"
+	// ExportSigFilename is the name of the export signature file inside the export zip
+	ExportSigFilename = "export.sig"
+
+	// tmpDirPrefix is the prefix of the temporary folders used while downloading an export
+	tmpDirPrefix = ".tmp-"
+)
+
+// Manifest describes a downloaded export and the checksums of its files
+type Manifest struct {
+	App       string            `json:"app"`
+	Export    string            `json:"export"`
+	URL       string            `json:"url"`
+	FetchedAt time.Time         `json:"fetchedAt"`
+	ZipSHA256 string            `json:"zipSha256"`
+	Files     map[string]string `json:"files"`
+}
+
+// AddFiles records the SHA-256 of the extracted files, relative to the dir folder
+func (m *Manifest) AddFiles(dir string, filenames []string) error {
+	for _, filename := range filenames {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			return err
+		}
+
+		sum, err := sha256File(filename)
+		if err != nil {
+			return err
+		}
+		m.Files[filepath.ToSlash(rel)] = sum
+	}
+	return nil
+}
+
+// WriteManifest writes the manifest in the dir folder
+func WriteManifest(dir string, m *Manifest) error {
+	b, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, ManifestFilename))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// ReadManifest reads the manifest of the export in the dir folder
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ManifestFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ExportDir is a downloaded export folder, laid out as workDir/app/export
+type ExportDir struct {
+	App    string
+	Export string
+	Path   string
+}
+
+// ListExportDirs returns the export folders found in the workDir, sorted by app and export.
+// Temporary folders left by an interrupted download are skipped.
+func ListExportDirs(workDir string) ([]ExportDir, error) {
+	dirs := make([]ExportDir, 0)
+
+	apps, err := ioutil.ReadDir(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range apps {
+		if !app.IsDir() {
+			continue
+		}
+
+		exports, err := ioutil.ReadDir(filepath.Join(workDir, app.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, export := range exports {
+			if !export.IsDir() || strings.HasPrefix(export.Name(), tmpDirPrefix) {
+				continue
+			}
+			dirs = append(dirs, ExportDir{
+				App:    app.Name(),
+				Export: export.Name(),
+				Path:   filepath.Join(workDir, app.Name(), export.Name()),
+			})
+		}
+	}
+
+	sort.SliceStable(dirs, func(i, j int) bool {
+		if dirs[i].App != dirs[j].App {
+			return dirs[i].App < dirs[j].App
+		}
+		return lessExport(dirs[i].Export, dirs[j].Export)
+	})
+
+	return dirs, nil
+}
+
+// ExportFile is an export.bin file, with the app and export of its folder when it was downloaded by gaen
+type ExportFile struct {
+	App    string
+	Export string
+	Path   string
+}
+
+// ExportFiles returns the export files of the paths. A path can be an export.bin file,
+// an export zip, an export folder containing an export.bin file, a folder of export zips,
+// an app folder containing export folders (and export zips), or a workDir with the workDir/app/export layout.
+func ExportFiles(paths []string) ([]ExportFile, error) {
+	files := make([]ExportFile, 0)
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, exportFileOf(path))
+			continue
+		}
+
+		bin := filepath.Join(path, ExportBinFilename)
+		if _, err := os.Stat(bin); err == nil {
+			files = append(files, exportFileOf(bin))
+			continue
+		}
+
+		// a folder can contain both export zips and export folders
+		zips, err := filepath.Glob(filepath.Join(path, "*.zip"))
+		if err != nil {
+			return nil, err
+		}
+		for _, zip := range zips {
+			files = append(files, ExportFile{Path: zip})
+		}
+
+		// an app folder is listed as a workDir with a single app
+		workDir := path
+		if isAppDir(path) {
+			workDir = filepath.Dir(filepath.Clean(path))
+		}
+
+		dirs, err := ListExportDirs(workDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			if workDir != path && dir.App != filepath.Base(filepath.Clean(path)) {
+				continue
+			}
+			// the folders without an export.bin, like the archive of a watch, are not exports
+			bin := filepath.Join(dir.Path, ExportBinFilename)
+			if _, err := os.Stat(bin); err != nil {
+				continue
+			}
+			files = append(files, ExportFile{App: dir.App, Export: dir.Export, Path: bin})
+		}
+	}
+
+	return files, nil
+}
+
+// fileExists returns true if the file exists
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// isAppDir returns true if the folder contains export folders
+func isAppDir(dir string) bool {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", ExportBinFilename))
+	return len(matches) > 0
+}
+
+// exportFileOf returns the ExportFile of the path, reading the app and export from its manifest if present
+func exportFileOf(path string) ExportFile {
+	file := ExportFile{Path: path}
+	if m, err := ReadManifest(filepath.Dir(path)); err == nil {
+		file.App = m.App
+		file.Export = m.Export
+	}
+	return file
+}
+
+// lessExport compares two export names, numerically when both of them are numbers
+func lessExport(a, b string) bool {
+	na, errA := strconv.ParseInt(a, 10, 64)
+	nb, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
+
+// sha256File returns the hex encoded SHA-256 of the file
+func sha256File(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
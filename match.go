@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"gaen/export"
+	"time"
+)
+
+// Window is a range of EN interval numbers, from From (included) to To (excluded).
+// A zero From or To leaves the range unbounded on that side.
+type Window struct {
+	From int
+	To   int
+}
+
+// NewWindow returns the Window between the two times. A zero time leaves the range unbounded on that side.
+func NewWindow(from, to time.Time) Window {
+	var w Window
+	if !from.IsZero() {
+		w.From = int(from.Unix() / 600)
+	}
+	if !to.IsZero() {
+		w.To = int((to.Unix() + 599) / 600)
+	}
+	return w
+}
+
+// Contains returns true if the interval is in the window
+func (w Window) Contains(interval int) bool {
+	return (w.From == 0 || interval >= w.From) && (w.To == 0 || interval < w.To)
+}
+
+// Overlaps returns true if any of the intervals of the key is in the window
+func (w Window) Overlaps(tek *TemporaryExposureKey) bool {
+	start := tek.RollingStartIntervalNumber
+	end := start + tek.RollingPeriod
+	return (w.From == 0 || end > w.From) && (w.To == 0 || start < w.To)
+}
+
+// rpiFunc is called for every RPI derived by forEachRPI
+type rpiFunc func(file ExportFile, exp *export.TemporaryExposureKeyExport, tek *TemporaryExposureKey, rpi *RollingProximityIdentifier) error
+
+// forEachRPI derives the RPIs in the window of the keys of the export files, calling fn for each of them.
+// Only the keys valid in the window are decoded, in parallel batches.
+func forEachRPI(files []ExportFile, window Window, fn rpiFunc) error {
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %s", file.Path, err)
+		}
+		if err := forEachExportRPI(file, exp, window, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachExportRPI is like forEachRPI, for an export already unmarshaled
+func forEachExportRPI(file ExportFile, exp *export.TemporaryExposureKeyExport, window Window, fn rpiFunc) error {
+	it, err := NewKeyIterator(exp, RPIsNone)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]*TemporaryExposureKey, 0, keyBatchSize)
+	flush := func() error {
+		if err := DecodeTEKs(batch); err != nil {
+			return err
+		}
+		for _, tek := range batch {
+			for _, rpi := range tek.RPIs {
+				if !window.Contains(rpi.IntervalNumber) {
+					continue
+				}
+				if err := fn(file, exp, tek, rpi); err != nil {
+					return err
+				}
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for it.Next() {
+		if !window.Overlaps(it.Key()) {
+			continue
+		}
+		batch = append(batch, it.Key())
+		if len(batch) == keyBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("%s: %s", file.Path, err)
+	}
+	return flush()
+}
+
+// ScanRPIs derives the RPIs in the window of the keys of the export files, returning the ones matching the rpis.
+// A key published in more than one export is returned once, with all its sources.
+func ScanRPIs(files []ExportFile, rpis []ID, window Window) ([]*RPIMatch, error) {
+	scanner := newRPIScanner(rpis)
+	err := forEachRPI(files, window, scanner.add)
+	return scanner.matches, err
+}
+
+// rpiScanner collects the matches of the wanted RPIs, with all their sources
+type rpiScanner struct {
+	wanted  map[string]bool
+	matches []*RPIMatch
+	found   map[string]*RPIMatch
+}
+
+// newRPIScanner returns an rpiScanner of the rpis
+func newRPIScanner(rpis []ID) *rpiScanner {
+	wanted := make(map[string]bool, len(rpis))
+	for _, rpi := range rpis {
+		wanted[string(rpi)] = true
+	}
+	return &rpiScanner{wanted: wanted, matches: make([]*RPIMatch, 0), found: make(map[string]*RPIMatch)}
+}
+
+// add is the rpiFunc that collects the RPI, if wanted
+func (s *rpiScanner) add(file ExportFile, exp *export.TemporaryExposureKeyExport, tek *TemporaryExposureKey, rpi *RollingProximityIdentifier) error {
+	if !s.wanted[string(rpi.ID)] {
+		return nil
+	}
+
+	ref := ExportRef{App: file.App, Export: file.Export, Region: exp.GetRegion()}
+	if ref.App == "" {
+		ref.Export = file.Path
+	}
+
+	if match, ok := s.found[string(rpi.ID)]; ok {
+		for _, source := range match.Sources {
+			if source == ref {
+				return nil
+			}
+		}
+		match.Sources = append(match.Sources, ref)
+		return nil
+	}
+
+	match := &RPIMatch{
+		RPI:                        append(ID(nil), rpi.ID...),
+		TEK:                        tek.ID,
+		IntervalNumber:             rpi.IntervalNumber,
+		Interval:                   rpi.Interval,
+		RollingStartIntervalNumber: tek.RollingStartIntervalNumber,
+		RollingPeriod:              tek.RollingPeriod,
+		Sources:                    []ExportRef{ref},
+	}
+	s.found[string(rpi.ID)] = match
+	s.matches = append(s.matches, match)
+	return nil
+}
+
+// Whois scans the export files for the key that generated the RPI.
+// If at is not zero only the keys valid within the tolerance around at are derived.
+func Whois(files []ExportFile, rpi ID, at time.Time, tolerance time.Duration) ([]*RPIMatch, error) {
+	window := Window{}
+	if !at.IsZero() {
+		interval := int(at.Unix() / 600)
+		intervals := int((tolerance + 10*time.Minute - 1) / (10 * time.Minute))
+		window = Window{From: interval - intervals, To: interval + intervals + 1}
+	}
+	return ScanRPIs(files, []ID{rpi}, window)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"gaen/export"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ExportHeader is the 16 bytes, space padded, header of the export.bin files
+const ExportHeader = "EK Export v1    "
+
+// DefaultMaxKeysPerExport is the default maximum number of keys in a single export file
+const DefaultMaxKeysPerExport = 30000
+
+// MergeOptions are the options used to merge the exports
+type MergeOptions struct {
+	// Window keeps only the keys with a rolling start interval number in the window
+	Window Window
+	// ReportTypes keeps only the keys with these report types, if not empty
+	ReportTypes []string
+	// Regions keeps only the exports of these regions, if not empty
+	Regions []string
+	// Region is the region of the merged exports. If empty, it is the region shared by all the exports.
+	Region string
+	// MaxKeys is the maximum number of keys in a single export
+	MaxKeys int
+	// Signer, if set, is used to add the signature infos to the exports
+	Signer *ExportSigner
+}
+
+// MergeExports merges the keys of the export files in a de-duplicated set, filters them and
+// splits them in batches of at most MaxKeys keys. When a key is published more than once,
+// the revision in the newest export (by end timestamp) is kept, so a revised report type or
+// days since onset of symptoms replaces the previous one. The revised keys of an export are newer
+// than its keys, and a key whose kept revision is a revised key is written in the revised keys of the
+// merged export. Between the revisions in exports with the same end timestamp, the one with the longest
+// rolling period is kept.
+func MergeExports(files []ExportFile, opts MergeOptions) ([]*export.TemporaryExposureKeyExport, error) {
+	if opts.MaxKeys < 1 {
+		return nil, fmt.Errorf("invalid max keys %d", opts.MaxKeys)
+	}
+
+	reportTypes := make(map[string]bool)
+	for _, rt := range opts.ReportTypes {
+		if _, ok := export.TemporaryExposureKey_ReportType_value[rt]; !ok {
+			return nil, fmt.Errorf("unknown report type [%s]", rt)
+		}
+		reportTypes[rt] = true
+	}
+	regions := make(map[string]bool)
+	for _, region := range opts.Regions {
+		regions[region] = true
+	}
+
+	// the newest revision of every key, with the end timestamp of its export
+	type revision struct {
+		key     *export.TemporaryExposureKey
+		end     uint64
+		revised bool
+	}
+	revisions := make(map[string]revision)
+	var start, end uint64
+	region := opts.Region
+	merged := 0
+
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		if len(regions) > 0 && !regions[exp.GetRegion()] {
+			continue
+		}
+
+		if opts.Region == "" {
+			if merged > 0 && region != exp.GetRegion() {
+				return nil, fmt.Errorf("the exports have different regions (%s, %s): set the region of the merged exports", region, exp.GetRegion())
+			}
+			region = exp.GetRegion()
+		}
+		if merged == 0 || exp.GetStartTimestamp() < start {
+			start = exp.GetStartTimestamp()
+		}
+		if exp.GetEndTimestamp() > end {
+			end = exp.GetEndTimestamp()
+		}
+		merged++
+
+		candidates := make([]revision, 0, len(exp.Keys)+len(exp.RevisedKeys))
+		for _, key := range exp.Keys {
+			candidates = append(candidates, revision{key: key, end: exp.GetEndTimestamp()})
+		}
+		for _, key := range exp.RevisedKeys {
+			candidates = append(candidates, revision{key: key, end: exp.GetEndTimestamp(), revised: true})
+		}
+		for _, rev := range candidates {
+			if seen, ok := revisions[string(rev.key.KeyData)]; ok {
+				if seen.end > rev.end {
+					continue
+				}
+				if seen.end == rev.end && seen.revised && !rev.revised {
+					continue
+				}
+				if seen.end == rev.end && seen.revised == rev.revised && seen.key.GetRollingPeriod() >= rev.key.GetRollingPeriod() {
+					continue
+				}
+			}
+			revisions[string(rev.key.KeyData)] = rev
+		}
+	}
+
+	// the keys are filtered after the de-duplication, so a key revised out of the filters is not merged,
+	// and sorted by key data, so their order leaks nothing about the uploads
+	sorted := make([]revision, 0, len(revisions))
+	for _, rev := range revisions {
+		key := rev.key
+		if !opts.Window.Contains(int(key.GetRollingStartIntervalNumber())) {
+			continue
+		}
+		if len(reportTypes) > 0 && !reportTypes[key.GetReportType().String()] {
+			continue
+		}
+		sorted = append(sorted, rev)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].key.KeyData, sorted[j].key.KeyData) < 0
+	})
+
+	batchSize := (len(sorted) + opts.MaxKeys - 1) / opts.MaxKeys
+	if batchSize == 0 {
+		batchSize = 1
+	}
+
+	exports := make([]*export.TemporaryExposureKeyExport, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		from := i * opts.MaxKeys
+		to := from + opts.MaxKeys
+		if to > len(sorted) {
+			to = len(sorted)
+		}
+
+		exp := &export.TemporaryExposureKeyExport{
+			StartTimestamp: proto.Uint64(start),
+			EndTimestamp:   proto.Uint64(end),
+			Region:         proto.String(region),
+			BatchNum:       proto.Int32(int32(i + 1)),
+			BatchSize:      proto.Int32(int32(batchSize)),
+		}
+		for _, rev := range sorted[from:to] {
+			if rev.revised {
+				exp.RevisedKeys = append(exp.RevisedKeys, rev.key)
+			} else {
+				exp.Keys = append(exp.Keys, rev.key)
+			}
+		}
+		if opts.Signer != nil {
+			exp.SignatureInfos = []*export.SignatureInfo{opts.Signer.SignatureInfo()}
+		}
+		exports = append(exports, exp)
+	}
+
+	return exports, nil
+}
+
+// MarshalExport returns the export.bin content of the export, with the header
+func MarshalExport(exp *export.TemporaryExposureKeyExport) ([]byte, error) {
+	b, err := proto.Marshal(exp)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(ExportHeader), b...), nil
+}
+
+// WriteExports writes the exports in the dir folder as export-<batch num>.zip files,
+// containing the export.bin and, if the signer is set, the export.sig files
+func WriteExports(dir string, exports []*export.TemporaryExposureKeyExport, signer *ExportSigner) ([]string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	filenames := make([]string, 0, len(exports))
+	for _, exp := range exports {
+		filename := filepath.Join(dir, "export-"+strconv.Itoa(int(exp.GetBatchNum()))+".zip")
+		if err := WriteExportZip(filename, exp, signer); err != nil {
+			return filenames, err
+		}
+		filenames = append(filenames, filename)
+	}
+	return filenames, nil
+}
+
+// WriteExportZip writes the export in a zip file, containing the export.bin and,
+// if the signer is set, the export.sig files. The zip is written to a temporary file
+// renamed in place, so the file is never left half written.
+func WriteExportZip(filename string, exp *export.TemporaryExposureKeyExport, signer *ExportSigner) error {
+	bin, err := MarshalExport(exp)
+	if err != nil {
+		return err
+	}
+
+	var sig []byte
+	if signer != nil {
+		if sig, err = signer.Sign(bin, exp.GetBatchNum(), exp.GetBatchSize()); err != nil {
+			return err
+		}
+	}
+
+	out, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	if err := writeExportZip(out, bin, sig); err != nil {
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(out.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), filename)
+}
+
+// writeExportZip writes a zip with the export.bin and, if not nil, the export.sig files
+func writeExportZip(w io.Writer, bin, sig []byte) error {
+	zw := zip.NewWriter(w)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{{ExportBinFilename, bin}, {ExportSigFilename, sig}} {
+		if entry.data == nil {
+			continue
+		}
+		f, err := zw.Create(entry.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(entry.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockServer serves a set of exports emulating the layouts of the backends:
+//
+//	/v1/keys/index            Immuni index, {"oldest": 1, "newest": n}
+//	/v1/keys/{n}              Immuni n-th export, from 1
+//	/v1/gaen/exposed/{ms}     SwissCovid export of the day starting at the unix time in ms (UTC)
+//	/index.txt                exposure-notifications-server index of the export zips
+//	/exports/{name}.zip       export zip listed in the index.txt
+//	POST /v1/publish          exposure-notifications-server v1 publish API, the keys are validated and discarded
+//	POST /api/verify          verification server device API, any code is exchanged for a single use token
+//	POST /api/certificate     verification server device API, the token is exchanged for an unsigned certificate
+//
+// The certificate of the published keys, if any, must carry the HMAC of the keys.
+//
+// The exports are ordered by start timestamp. Only the last export starting in a day is served as the SwissCovid
+// export of the day, and the SwissCovid downloader finds it only if the day is one of the last three.
+type MockServer struct {
+	exports []mockExport
+	byDay   map[int64]int
+	byName  map[string]int
+
+	mu     sync.Mutex
+	tokens map[string]bool
+}
+
+// mockExport is an export served by the MockServer
+type mockExport struct {
+	name  string
+	start uint64
+	zip   []byte
+}
+
+// NewMockServer returns a MockServer serving the export files. The export.bin files are zipped
+// together with their export.sig, if any.
+func NewMockServer(files []ExportFile) (*MockServer, error) {
+	s := &MockServer{
+		exports: make([]mockExport, 0, len(files)),
+		byDay:   make(map[int64]int),
+		byName:  make(map[string]int),
+		tokens:  make(map[string]bool),
+	}
+
+	for _, file := range files {
+		bin, sig, err := ReadExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		exp, err := UnmarshalExport(bin)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+
+		var buf bytes.Buffer
+		if err := writeExportZip(&buf, bin, sig); err != nil {
+			return nil, err
+		}
+
+		s.exports = append(s.exports, mockExport{
+			name:  fmt.Sprintf("%d-%d-%05d", exp.GetStartTimestamp(), exp.GetEndTimestamp(), exp.GetBatchNum()),
+			start: exp.GetStartTimestamp(),
+			zip:   buf.Bytes(),
+		})
+	}
+
+	sort.SliceStable(s.exports, func(i, j int) bool {
+		return s.exports[i].start < s.exports[j].start
+	})
+	for i, e := range s.exports {
+		day := time.Unix(int64(e.start), 0).UTC().Truncate(24 * time.Hour)
+		s.byDay[day.Unix()*1000] = i
+		s.byName[e.name] = i
+	}
+
+	return s, nil
+}
+
+// Len returns the number of exports served
+func (s *MockServer) Len() int {
+	return len(s.exports)
+}
+
+// ServeHTTP serves the index and the exports
+func (s *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		switch r.URL.Path {
+		case "/v1/publish":
+			s.servePublish(w, r)
+			return
+		case "/api/verify":
+			s.serveVerify(w, r)
+			return
+		case "/api/certificate":
+			s.serveCertificate(w, r)
+			return
+		}
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/v1/keys/index":
+		s.serveImmuniIndex(w)
+	case strings.HasPrefix(path, "/v1/keys/"):
+		n, err := strconv.Atoi(strings.TrimPrefix(path, "/v1/keys/"))
+		if err != nil || n < 1 || n > len(s.exports) {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveExport(w, n-1)
+	case strings.HasPrefix(path, "/v1/gaen/exposed/"):
+		ms, err := strconv.ParseInt(strings.TrimPrefix(path, "/v1/gaen/exposed/"), 10, 64)
+		i, ok := s.byDay[ms]
+		if err != nil || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveExport(w, i)
+	case path == "/index.txt":
+		w.Header().Set("Content-Type", "text/plain")
+		for _, e := range s.exports {
+			fmt.Fprintf(w, "exports/%s.zip\n", e.name)
+		}
+	case strings.HasPrefix(path, "/exports/") && strings.HasSuffix(path, ".zip"):
+		i, ok := s.byName[strings.TrimSuffix(strings.TrimPrefix(path, "/exports/"), ".zip")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveExport(w, i)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveImmuniIndex serves the index of the Immuni exports
+func (s *MockServer) serveImmuniIndex(w http.ResponseWriter) {
+	if len(s.exports) == 0 {
+		http.Error(w, "no exports", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"oldest": 1, "newest": len(s.exports)})
+}
+
+// servePublish validates the keys of a publish request
+func (s *MockServer) servePublish(w http.ResponseWriter, r *http.Request) {
+	var req PublishRequest
+	resp := PublishResponse{}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, DefaultMaxBodySize)).Decode(&req); err != nil {
+		resp.Error, resp.Code = err.Error(), "unparsable_request"
+	} else if req.HealthAuthorityID == "" {
+		resp.Error, resp.Code = "missing healthAuthorityID", "unknown_health_authority_id"
+	} else if len(req.Keys) == 0 {
+		resp.Error, resp.Code = "no temporaryExposureKeys", "missing_exposures"
+	} else {
+		for _, key := range req.Keys {
+			if b, err := base64.StdEncoding.DecodeString(key.Key); err != nil || len(b) != 16 {
+				resp.Error, resp.Code = fmt.Sprintf("invalid key %s", key.Key), "invalid_keys"
+			} else if key.RollingPeriod < 1 || key.RollingPeriod > 144 {
+				resp.Error, resp.Code = fmt.Sprintf("invalid rolling period %d of key %s", key.RollingPeriod, key.Key), "invalid_keys"
+			}
+		}
+		if resp.Error == "" && req.VerificationPayload != "" {
+			if err := checkMockCertificate(req); err != nil {
+				resp.Error, resp.Code = err.Error(), "health_authority_verification_certificate_invalid"
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if resp.Error != "" {
+		status = http.StatusBadRequest
+	} else {
+		token := make([]byte, 32)
+		rand.Read(token)
+		resp.RevisionToken = base64.StdEncoding.EncodeToString(token)
+		resp.InsertedExposures = len(req.Keys)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveVerify exchanges any verification code for a confirmed test token
+func (s *MockServer) serveVerify(w http.ResponseWriter, r *http.Request) {
+	var req VerifyCodeRequest
+	resp := VerifyCodeResponse{}
+
+	if r.Header.Get("X-API-Key") == "" {
+		resp.Error, resp.ErrorCode = "missing API key", "unauthorized"
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, DefaultMaxBodySize)).Decode(&req); err != nil {
+		resp.Error, resp.ErrorCode = err.Error(), "unparsable_request"
+	} else if req.Code == "" {
+		resp.Error, resp.ErrorCode = "missing code", "code_invalid"
+	} else {
+		token := make([]byte, 32)
+		rand.Read(token)
+		resp.Token = base64.StdEncoding.EncodeToString(token)
+		resp.TestType = "confirmed"
+		resp.TestDate = time.Now().UTC().Format("2006-01-02")
+
+		s.mu.Lock()
+		s.tokens[resp.Token] = true
+		s.mu.Unlock()
+	}
+
+	writeMockResponse(w, resp, resp.Error)
+}
+
+// serveCertificate exchanges a token of serveVerify for an unsigned certificate with the HMAC of the keys
+func (s *MockServer) serveCertificate(w http.ResponseWriter, r *http.Request) {
+	var req CertificateRequest
+	resp := CertificateResponse{}
+
+	if r.Header.Get("X-API-Key") == "" {
+		resp.Error, resp.ErrorCode = "missing API key", "unauthorized"
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, DefaultMaxBodySize)).Decode(&req); err != nil {
+		resp.Error, resp.ErrorCode = err.Error(), "unparsable_request"
+	} else if b, err := base64.StdEncoding.DecodeString(req.ExposureKeyHMAC); err != nil || len(b) != 32 {
+		resp.Error, resp.ErrorCode = "invalid ekeyhmac", "hmac_invalid"
+	} else {
+		// the tokens are single use
+		s.mu.Lock()
+		valid := s.tokens[req.Token]
+		delete(s.tokens, req.Token)
+		s.mu.Unlock()
+
+		if !valid {
+			resp.Error, resp.ErrorCode = "invalid or used token", "token_invalid"
+		} else {
+			header, _ := json.Marshal(map[string]string{"alg": "none", "typ": "JWT"})
+			claims, _ := json.Marshal(map[string]string{"iss": "gaen-mock", "reportType": "confirmed", "tekmac": req.ExposureKeyHMAC})
+			resp.Certificate = base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims) + "."
+		}
+	}
+
+	writeMockResponse(w, resp, resp.Error)
+}
+
+// checkMockCertificate checks that the certificate of serveCertificate carries the HMAC of the published keys
+func checkMockCertificate(req PublishRequest) error {
+	parts := strings.Split(req.VerificationPayload, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid certificate")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %s", err)
+	}
+	var claims struct {
+		TEKMAC string `json:"tekmac"`
+	}
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return fmt.Errorf("invalid certificate: %s", err)
+	}
+
+	hmacKey, err := base64.StdEncoding.DecodeString(req.HMACKey)
+	if err != nil {
+		return fmt.Errorf("invalid hmacKey: %s", err)
+	}
+	mac, err := ExposureKeyHMAC(req.Keys, hmacKey)
+	if err != nil {
+		return err
+	}
+	if claims.TEKMAC != base64.StdEncoding.EncodeToString(mac) {
+		return fmt.Errorf("the HMAC of the keys does not match the certificate")
+	}
+	return nil
+}
+
+// writeMockResponse writes the JSON response, with the 400 status code if there is an error
+func writeMockResponse(w http.ResponseWriter, resp interface{}, errMsg string) {
+	status := http.StatusOK
+	if errMsg != "" {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveExport serves the i-th export zip
+func (s *MockServer) serveExport(w http.ResponseWriter, i int) {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Length", strconv.Itoa(len(s.exports[i].zip)))
+	w.Write(s.exports[i].zip)
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// Output formats supported by the KeyWriter
+const (
+	OutputJSON      = "json"
+	OutputNDJSON    = "ndjson"
+	OutputCSV       = "csv"
+	OutputTable     = "table"
+	OutputPrototext = "prototext"
+)
+
+// Rows written by the KeyWriter
+const (
+	RowsTEK = "tek"
+	RowsRPI = "rpi"
+)
+
+// OutputOptions are the options of a KeyWriter
+type OutputOptions struct {
+	// Format is one of the Output formats
+	Format string
+	// Rows is RowsTEK to write a row per TEK, or RowsRPI to write a row per RPI
+	Rows string
+	// Query is an optional JMESPath query, supported only by the json and ndjson formats.
+	// With json it is applied to the whole list, with ndjson to every row.
+	Query string
+	// Padding adds the padding score of the TEKs to the csv and table formats
+	Padding bool
+	// Origins adds the origin and the visited countries of the TEKs to the csv and table formats
+	Origins bool
+	// IDFormat is the format of the IDs, base64 if empty
+	IDFormat IDFormat
+}
+
+// NeedsRPIs returns true if the RPIs of every key are written with these options
+func (opts OutputOptions) NeedsRPIs() bool {
+	return opts.Rows == RowsRPI || opts.Format == OutputJSON || opts.Format == OutputNDJSON
+}
+
+// KeyWriter writes the decoded TemporaryExposureKeys in a specific format
+type KeyWriter interface {
+	Write(tek *TemporaryExposureKey) error
+	Close() error
+}
+
+// NewKeyWriter returns the KeyWriter for the specified options.
+// The prototext format is not a KeyWriter format, since it prints the raw export.
+func NewKeyWriter(w io.Writer, opts OutputOptions) (KeyWriter, error) {
+	if opts.Rows != RowsTEK && opts.Rows != RowsRPI {
+		return nil, fmt.Errorf("unknown rows [%s]: use %s or %s", opts.Rows, RowsTEK, RowsRPI)
+	}
+
+	if opts.Query != "" && opts.Format != OutputJSON && opts.Format != OutputNDJSON {
+		return nil, fmt.Errorf("query is supported only with the %s and %s output", OutputJSON, OutputNDJSON)
+	}
+
+	switch opts.Format {
+	case OutputJSON:
+		return &jsonKeyWriter{w: w, opts: opts, records: make([]interface{}, 0)}, nil
+	case OutputNDJSON:
+		return &ndjsonKeyWriter{w: w, opts: opts}, nil
+	case OutputCSV:
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write(header(opts)); err != nil {
+			return nil, err
+		}
+		return &csvKeyWriter{w: csvWriter, opts: opts}, nil
+	case OutputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(tw, strings.ToUpper(strings.Join(header(opts), "\t"))); err != nil {
+			return nil, err
+		}
+		return &tableKeyWriter{w: tw, opts: opts}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output [%s]", opts.Format)
+}
+
+// jsonKeyWriter writes an indented JSON array of all the rows.
+// Without a query the rows are streamed, otherwise they are collected to apply the query on Close.
+type jsonKeyWriter struct {
+	w       io.Writer
+	opts    OutputOptions
+	records []interface{}
+	written int
+}
+
+func (jw *jsonKeyWriter) Write(tek *TemporaryExposureKey) error {
+	recs, err := records(tek, jw.opts, true)
+	if err != nil {
+		return err
+	}
+
+	if jw.opts.Query != "" {
+		jw.records = append(jw.records, recs...)
+		return nil
+	}
+
+	for _, record := range recs {
+		b, err := json.MarshalIndent(record, "    ", "    ")
+		if err != nil {
+			return err
+		}
+
+		sep := ",\n    "
+		if jw.written == 0 {
+			sep = "[\n    "
+		}
+		if _, err := io.WriteString(jw.w, sep+string(b)); err != nil {
+			return err
+		}
+		jw.written++
+	}
+	return nil
+}
+
+func (jw *jsonKeyWriter) Close() error {
+	if jw.opts.Query == "" {
+		end := "\n]\n"
+		if jw.written == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(jw.w, end)
+		return err
+	}
+
+	out, err := search(jw.opts.Query, jw.records)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(jw.w, string(b))
+	return err
+}
+
+// ndjsonKeyWriter writes a JSON object per line, one for every row
+type ndjsonKeyWriter struct {
+	w    io.Writer
+	opts OutputOptions
+}
+
+func (nw *ndjsonKeyWriter) Write(tek *TemporaryExposureKey) error {
+	recs, err := records(tek, nw.opts, true)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range recs {
+		if nw.opts.Query != "" {
+			var err error
+			record, err = search(nw.opts.Query, record)
+			if err != nil {
+				return err
+			}
+		}
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(nw.w, string(b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (nw *ndjsonKeyWriter) Close() error {
+	return nil
+}
+
+// csvKeyWriter writes a CSV line for every row, with a header line
+type csvKeyWriter struct {
+	w    *csv.Writer
+	opts OutputOptions
+}
+
+func (cw *csvKeyWriter) Write(tek *TemporaryExposureKey) error {
+	recs, err := records(tek, cw.opts, false)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range recs {
+		if err := cw.w.Write(values(record, cw.opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cw *csvKeyWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// tableKeyWriter writes an aligned table with a line for every row
+type tableKeyWriter struct {
+	w    *tabwriter.Writer
+	opts OutputOptions
+}
+
+func (tw *tableKeyWriter) Write(tek *TemporaryExposureKey) error {
+	recs, err := records(tek, tw.opts, false)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range recs {
+		if _, err := fmt.Fprintln(tw.w, strings.Join(values(record, tw.opts), "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tw *tableKeyWriter) Close() error {
+	return tw.w.Flush()
+}
+
+// search applies the JMESPath query to the JSON representation of the data,
+// so the query sees the values (e.g. the IDs) exactly as they are written
+func search(query string, data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return jmespath.Search(query, v)
+}
+
+// tekRow is the row written for every TEK, with the IDs in the IDFormat
+type tekRow struct {
+	ID                         FormattedID `json:"ID"`
+	Date                       JSONTime
+	RollingStartIntervalNumber int
+	RollingPeriod              int
+	RPIs                       []*rpiJSON `json:",omitempty"`
+	PaddingScore               float64    `json:",omitempty"`
+	Origin                     string     `json:",omitempty"`
+	VisitedCountries           []string   `json:",omitempty"`
+}
+
+// rpiJSON is an RPI of a tekRow
+type rpiJSON struct {
+	ID             FormattedID `json:"ID"`
+	IntervalNumber int         `json:"IntervalNumber"`
+	Interval       time.Time   `json:"Interval"`
+}
+
+// rpiRow is the row written for every RPI, with the TEK that generated it
+type rpiRow struct {
+	TEK            FormattedID `json:"TEK"`
+	ID             FormattedID `json:"ID"`
+	IntervalNumber int         `json:"IntervalNumber"`
+	Interval       time.Time   `json:"Interval"`
+}
+
+// records returns the rows of the TEK. The RPIs of a lazily decoded TEK are derived
+// only if the rows are per RPI, or if withRPIs is set because they are part of the TEK row.
+func records(tek *TemporaryExposureKey, opts OutputOptions, withRPIs bool) ([]interface{}, error) {
+	if opts.Rows == RowsRPI || withRPIs {
+		if err := tek.LoadRPIs(); err != nil {
+			return nil, err
+		}
+	}
+
+	f := opts.IDFormat
+	if opts.Rows == RowsTEK {
+		row := &tekRow{
+			ID:                         f.ID(tek.ID),
+			Date:                       tek.Date,
+			RollingStartIntervalNumber: tek.RollingStartIntervalNumber,
+			RollingPeriod:              tek.RollingPeriod,
+			PaddingScore:               tek.PaddingScore,
+			Origin:                     tek.Origin,
+			VisitedCountries:           tek.VisitedCountries,
+		}
+		for _, rpi := range tek.RPIs {
+			row.RPIs = append(row.RPIs, &rpiJSON{ID: f.ID(rpi.ID), IntervalNumber: rpi.IntervalNumber, Interval: rpi.Interval})
+		}
+		return []interface{}{row}, nil
+	}
+
+	records := make([]interface{}, 0, len(tek.RPIs))
+	for _, rpi := range tek.RPIs {
+		records = append(records, &rpiRow{
+			TEK:            f.ID(tek.ID),
+			ID:             f.ID(rpi.ID),
+			IntervalNumber: rpi.IntervalNumber,
+			Interval:       rpi.Interval,
+		})
+	}
+	return records, nil
+}
+
+// header returns the columns of the csv and table formats
+func header(opts OutputOptions) []string {
+	if opts.Rows == RowsTEK {
+		columns := []string{"id", "date", "rolling_start_interval_number", "rolling_period"}
+		if opts.Padding {
+			columns = append(columns, "padding_score")
+		}
+		if opts.Origins {
+			columns = append(columns, "origin", "visited_countries")
+		}
+		return columns
+	}
+	return []string{"tek", "id", "interval_number", "interval"}
+}
+
+// values returns the values of the row for the csv and table formats
+func values(record interface{}, opts OutputOptions) []string {
+	switch r := record.(type) {
+	case *tekRow:
+		values := []string{
+			r.ID.String(),
+			r.Date.String(),
+			strconv.Itoa(r.RollingStartIntervalNumber),
+			strconv.Itoa(r.RollingPeriod),
+		}
+		if opts.Padding {
+			values = append(values, strconv.FormatFloat(r.PaddingScore, 'f', 2, 64))
+		}
+		if opts.Origins {
+			values = append(values, r.Origin, strings.Join(r.VisitedCountries, ","))
+		}
+		return values
+	case *rpiRow:
+		return []string{
+			r.TEK.String(),
+			r.ID.String(),
+			strconv.Itoa(r.IntervalNumber),
+			r.Interval.Format(time.RFC3339),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"gaen/export"
+	"sort"
+)
+
+// Heuristics used to detect the padding keys
+const (
+	// PaddingIdenticalAttributes flags the keys sharing the same start interval, rolling period,
+	// transmission risk level, report type and days since onset far more often than expected
+	PaddingIdenticalAttributes = "identical-attributes"
+	// PaddingUniformCounts flags the exports with exactly the same number of keys for every key date
+	PaddingUniformCounts = "uniform-counts"
+	// PaddingRoundCount flags the exports with a number of keys that is a multiple of 1000
+	PaddingRoundCount = "round-count"
+	// PaddingNeverReappearing flags the keys never published again in the later exports of an app
+	// that usually publishes the keys again, while they were still retained
+	PaddingNeverReappearing = "never-reappearing"
+)
+
+// paddingRetention is the time, in seconds, the keys are retained by the servers after the end of their rolling period
+const paddingRetention = 14 * 24 * 60 * 60
+
+// likelyPaddingScore is the score from which a key is considered likely padding
+const likelyPaddingScore = 0.5
+
+// PaddingReport is the result of the detection of the padding keys in a set of exports
+type PaddingReport struct {
+	Keys          int
+	LikelyPadding int
+	// EstimatedRealKeys are the keys not considered likely padding
+	EstimatedRealKeys int
+	// Confidence is the average padding score of the likely padding keys
+	Confidence float64
+	Findings   []PaddingFinding
+	scores     map[string]float64
+}
+
+// PaddingFinding is a group of keys flagged by one of the heuristics
+type PaddingFinding struct {
+	Heuristic string
+	Export    string
+	Keys      int
+	Score     float64
+	Detail    string
+}
+
+// paddingExport is an export analyzed by DetectPadding
+type paddingExport struct {
+	name string
+	app  string
+	exp  *export.TemporaryExposureKeyExport
+}
+
+// DetectPadding scores the keys of the export files with a set of heuristics, from 0 (real) to 1 (padding)
+func DetectPadding(files []ExportFile) (*PaddingReport, error) {
+	exports := make([]paddingExport, 0, len(files))
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+
+		name := file.Path
+		if file.App != "" {
+			name = file.App + "/" + file.Export
+		}
+		exports = append(exports, paddingExport{name: name, app: file.App, exp: exp})
+	}
+	return detectPadding(exports), nil
+}
+
+// detectPadding runs all the heuristics on the exports
+func detectPadding(exports []paddingExport) *PaddingReport {
+	report := &PaddingReport{
+		Findings: make([]PaddingFinding, 0),
+		scores:   make(map[string]float64),
+	}
+
+	for _, pe := range exports {
+		for _, key := range pe.exp.Keys {
+			if _, ok := report.scores[string(key.KeyData)]; !ok {
+				report.scores[string(key.KeyData)] = 0
+			}
+		}
+		report.identicalAttributes(pe)
+		report.uniformCounts(pe)
+		report.roundCount(pe)
+	}
+	report.neverReappearing(exports)
+
+	report.Keys = len(report.scores)
+	total := 0.0
+	for _, score := range report.scores {
+		if score >= likelyPaddingScore {
+			report.LikelyPadding++
+			total += score
+		}
+	}
+	report.EstimatedRealKeys = report.Keys - report.LikelyPadding
+	if report.LikelyPadding > 0 {
+		report.Confidence = total / float64(report.LikelyPadding)
+	}
+
+	return report
+}
+
+// Score returns the padding score of the key, from 0 (real) to 1 (padding)
+func (r *PaddingReport) Score(keyData []byte) float64 {
+	return r.scores[string(keyData)]
+}
+
+// flag records a finding, raising the score of its keys
+func (r *PaddingReport) flag(finding PaddingFinding, keys []*export.TemporaryExposureKey) {
+	finding.Keys = len(keys)
+	r.Findings = append(r.Findings, finding)
+	for _, key := range keys {
+		if finding.Score > r.scores[string(key.KeyData)] {
+			r.scores[string(key.KeyData)] = finding.Score
+		}
+	}
+}
+
+// identicalAttributes flags the groups of keys with the same attributes that are much bigger
+// than expected if the attributes were independent
+func (r *PaddingReport) identicalAttributes(pe paddingExport) {
+	keys := pe.exp.Keys
+	n := float64(len(keys))
+	if n < 10 {
+		return
+	}
+
+	attrs := func(key *export.TemporaryExposureKey) [5]string {
+		return [5]string{
+			optionalInt(key.RollingStartIntervalNumber),
+			optionalInt(key.RollingPeriod),
+			optionalInt(key.TransmissionRiskLevel),
+			key.GetReportType().String(),
+			optionalInt(key.DaysSinceOnsetOfSymptoms),
+		}
+	}
+
+	var marginals [5]map[string]int
+	for i := range marginals {
+		marginals[i] = make(map[string]int)
+	}
+	groups := make(map[[5]string][]*export.TemporaryExposureKey)
+	for _, key := range keys {
+		a := attrs(key)
+		for i, v := range a {
+			marginals[i][v]++
+		}
+		groups[a] = append(groups[a], key)
+	}
+
+	signatures := make([][5]string, 0, len(groups))
+	for a := range groups {
+		signatures = append(signatures, a)
+	}
+	sort.Slice(signatures, func(i, j int) bool {
+		return fmt.Sprint(signatures[i]) < fmt.Sprint(signatures[j])
+	})
+
+	for _, a := range signatures {
+		group := groups[a]
+		if len(group) < 10 {
+			continue
+		}
+
+		expected := n
+		for i, v := range a {
+			expected *= float64(marginals[i][v]) / n
+		}
+		ratio := float64(len(group)) / expected
+		if ratio < 3 {
+			continue
+		}
+
+		r.flag(PaddingFinding{
+			Heuristic: PaddingIdenticalAttributes,
+			Export:    pe.name,
+			Score:     1 - 1/ratio,
+			Detail: fmt.Sprintf("%d keys with start interval %s, rolling period %s, risk level %s, report type %s, days since onset %s (%.1f expected)",
+				len(group), a[0], a[1], a[2], a[3], a[4], expected),
+		}, group)
+	}
+}
+
+// uniformCounts flags the exports with the same number of keys for every key date
+func (r *PaddingReport) uniformCounts(pe paddingExport) {
+	perDay := make(map[int32]int)
+	for _, key := range pe.exp.Keys {
+		perDay[key.GetRollingStartIntervalNumber()/144]++
+	}
+	if len(perDay) < 3 {
+		return
+	}
+
+	count := -1
+	for _, c := range perDay {
+		if count != -1 && c != count {
+			return
+		}
+		count = c
+	}
+	if count < 10 {
+		return
+	}
+
+	r.flag(PaddingFinding{
+		Heuristic: PaddingUniformCounts,
+		Export:    pe.name,
+		Score:     0.7,
+		Detail:    fmt.Sprintf("exactly %d keys for each of the %d key dates", count, len(perDay)),
+	}, pe.exp.Keys)
+}
+
+// roundCount flags the exports with a number of keys that is a multiple of 1000
+func (r *PaddingReport) roundCount(pe paddingExport) {
+	n := len(pe.exp.Keys)
+	if n == 0 || n%1000 != 0 {
+		return
+	}
+
+	r.flag(PaddingFinding{
+		Heuristic: PaddingRoundCount,
+		Export:    pe.name,
+		Score:     0.3,
+		Detail:    fmt.Sprintf("%d keys, a multiple of 1000", n),
+	}, pe.exp.Keys)
+}
+
+// neverReappearing flags, for the apps that usually publish the keys again in the later exports,
+// the keys that are never published again. Only the keys that expired before the newest export of the app,
+// and that were still retained when a later export was published, are considered: the other keys could
+// not be published again.
+func (r *PaddingReport) neverReappearing(exports []paddingExport) {
+	byApp := make(map[string][]paddingExport)
+	apps := make([]string, 0)
+	for _, pe := range exports {
+		if pe.app == "" {
+			continue
+		}
+		if _, ok := byApp[pe.app]; !ok {
+			apps = append(apps, pe.app)
+		}
+		byApp[pe.app] = append(byApp[pe.app], pe)
+	}
+
+	for _, app := range apps {
+		appExports := byApp[app]
+		if len(appExports) < 2 {
+			continue
+		}
+		sort.SliceStable(appExports, func(i, j int) bool {
+			return appExports[i].exp.GetStartTimestamp() < appExports[j].exp.GetStartTimestamp()
+		})
+		newest := appExports[len(appExports)-1].exp.GetStartTimestamp()
+
+		// the last export where every key was published
+		last := make(map[string]int)
+		for i, pe := range appExports {
+			for _, key := range pe.exp.Keys {
+				last[string(key.KeyData)] = i
+			}
+		}
+
+		for i, pe := range appExports[:len(appExports)-1] {
+			next := appExports[i+1].exp.GetStartTimestamp()
+
+			expected := 0
+			never := make([]*export.TemporaryExposureKey, 0)
+			for _, key := range pe.exp.Keys {
+				expiry := uint64(key.GetRollingStartIntervalNumber()+key.GetRollingPeriod())*600 + paddingRetention
+				if expiry <= next || expiry > newest {
+					continue
+				}
+				expected++
+				if last[string(key.KeyData)] == i {
+					never = append(never, key)
+				}
+			}
+			if expected == 0 || len(never) == 0 {
+				continue
+			}
+
+			reappearing := 1 - float64(len(never))/float64(expected)
+			if reappearing < 0.2 {
+				continue
+			}
+
+			r.flag(PaddingFinding{
+				Heuristic: PaddingNeverReappearing,
+				Export:    pe.name,
+				Score:     reappearing,
+				Detail: fmt.Sprintf("%d keys never published again while retained, while %.0f%% of the %d retained keys of the export were",
+					len(never), reappearing*100, expected),
+			}, never)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// DefaultPublishURL is the default url of the publish endpoint, of a local exposure-notifications-server
+const DefaultPublishURL = "http://localhost:8080/v1/publish"
+
+// PaddedRequestSize is the size in bytes the JSON requests are padded to a multiple of,
+// enough for a publish request of 30 keys with its certificate
+const PaddedRequestSize = 8 << 10
+
+// PublishKey is a key of a PublishRequest
+type PublishKey struct {
+	Key                string `json:"key"`
+	RollingStartNumber int32  `json:"rollingStartNumber"`
+	RollingPeriod      int32  `json:"rollingPeriod"`
+	TransmissionRisk   int    `json:"transmissionRisk"`
+}
+
+// PublishRequest is the body of the v1 publish API of the exposure-notifications-server
+type PublishRequest struct {
+	Keys                 []PublishKey `json:"temporaryExposureKeys"`
+	HealthAuthorityID    string       `json:"healthAuthorityID"`
+	VerificationPayload  string       `json:"verificationPayload,omitempty"`
+	HMACKey              string       `json:"hmacKey,omitempty"`
+	SymptomOnsetInterval int32        `json:"symptomOnsetInterval,omitempty"`
+	Traveler             bool         `json:"traveler,omitempty"`
+	RevisionToken        string       `json:"revisionToken,omitempty"`
+	Padding              string       `json:"padding"`
+}
+
+// PublishResponse is the response of the v1 publish API
+type PublishResponse struct {
+	RevisionToken     string   `json:"revisionToken"`
+	InsertedExposures int      `json:"insertedExposures"`
+	Error             string   `json:"error,omitempty"`
+	Code              string   `json:"code,omitempty"`
+	Warnings          []string `json:"warnings,omitempty"`
+}
+
+// PublishOptions are the options of the PublishRequest
+type PublishOptions struct {
+	HealthAuthorityID string
+	// VerificationPayload is the verification certificate
+	VerificationPayload string
+	// HMACKey is the key of the HMAC of the keys in the certificate. A random key is used if nil.
+	HMACKey []byte
+	// TransmissionRisk is the transmission risk of all the keys
+	TransmissionRisk int
+	Traveler         bool
+	RevisionToken    string
+}
+
+// NewPublishRequest returns the PublishRequest of the keys, with a random padding
+func NewPublishRequest(teks []*TemporaryExposureKey, opts PublishOptions) (*PublishRequest, error) {
+	if opts.HealthAuthorityID == "" {
+		return nil, fmt.Errorf("the health authority ID is required")
+	}
+
+	hmacKey := opts.HMACKey
+	if hmacKey == nil {
+		hmacKey = make([]byte, 16)
+		if _, err := rand.Read(hmacKey); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &PublishRequest{
+		Keys:                NewPublishKeys(teks, opts.TransmissionRisk),
+		HealthAuthorityID:   opts.HealthAuthorityID,
+		VerificationPayload: opts.VerificationPayload,
+		HMACKey:             base64.StdEncoding.EncodeToString(hmacKey),
+		Traveler:            opts.Traveler,
+		RevisionToken:       opts.RevisionToken,
+	}
+	// the padding hides the number of keys from who observes the size of the request
+	if err := padRequest(req, &req.Padding); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// padRequest sets the padding of the request to a random base64 string that brings the size of its JSON
+// up to the next multiple of PaddedRequestSize (within 3 bytes), whatever the content of the request
+func padRequest(req interface{}, padding *string) error {
+	*padding = ""
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	size := (len(b)/PaddedRequestSize + 1) * PaddedRequestSize
+	random := make([]byte, (size-len(b))/4*3)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	*padding = base64.StdEncoding.EncodeToString(random)
+	return nil
+}
+
+// NewPublishKeys returns the PublishKeys of the keys, with the same transmission risk
+func NewPublishKeys(teks []*TemporaryExposureKey, transmissionRisk int) []PublishKey {
+	keys := make([]PublishKey, 0, len(teks))
+	for _, tek := range teks {
+		keys = append(keys, PublishKey{
+			Key:                tek.ID.ToBase64(),
+			RollingStartNumber: int32(tek.RollingStartIntervalNumber),
+			RollingPeriod:      int32(tek.RollingPeriod),
+			TransmissionRisk:   transmissionRisk,
+		})
+	}
+	return keys
+}
+
+// Publish posts the PublishRequest to the url of a publish endpoint
+func Publish(url string, req *PublishRequest) (*PublishResponse, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PublishResponse
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return nil, fmt.Errorf("error publishing the keys. Status code %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if resp.StatusCode != http.StatusOK || pr.Error != "" {
+		return &pr, fmt.Errorf("error publishing the keys. Status code %d: %s %s", resp.StatusCode, pr.Code, pr.Error)
+	}
+	return &pr, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"gaen/export"
+	"io/ioutil"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ECDSAWithSHA256 is the OID of the ECDSA with SHA-256 signature algorithm used by the exports
+const ECDSAWithSHA256 = "1.2.840.10045.4.3.2"
+
+// ExportSigner signs the export files with an ECDSA P-256 key
+type ExportSigner struct {
+	Key        *ecdsa.PrivateKey
+	KeyID      string
+	KeyVersion string
+}
+
+// LoadExportSigner loads the ExportSigner from a PEM encoded private key (PKCS#8 or SEC 1)
+func LoadExportSigner(filename, keyID, keyVersion string) (*ExportSigner, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", filename)
+	}
+
+	var key *ecdsa.PrivateKey
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		ecKey, ok := parsed.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an ECDSA private key", filename)
+		}
+		key = ecKey
+	} else if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+
+	return &ExportSigner{Key: key, KeyID: keyID, KeyVersion: keyVersion}, nil
+}
+
+// SignatureInfo returns the SignatureInfo of the signer
+func (s *ExportSigner) SignatureInfo() *export.SignatureInfo {
+	return &export.SignatureInfo{
+		VerificationKeyVersion: proto.String(s.KeyVersion),
+		VerificationKeyId:      proto.String(s.KeyID),
+		SignatureAlgorithm:     proto.String(ECDSAWithSHA256),
+	}
+}
+
+// Sign returns the marshaled TEKSignatureList with the signature of the export.bin data
+func (s *ExportSigner) Sign(data []byte, batchNum, batchSize int32) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, s.Key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&export.TEKSignatureList{
+		Signatures: []*export.TEKSignature{{
+			SignatureInfo: s.SignatureInfo(),
+			BatchNum:      proto.Int32(batchNum),
+			BatchSize:     proto.Int32(batchSize),
+			Signature:     sig,
+		}},
+	})
+}
+
+// LoadPublicKey loads an ECDSA P-256 public key from a PEM encoded PKIX public key
+func LoadPublicKey(filename string) (*ecdsa.PublicKey, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", filename)
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an ECDSA public key", filename)
+	}
+	return key, nil
+}
+
+// SignatureCheck is the result of the verification of a signature of an export
+type SignatureCheck struct {
+	KeyID      string
+	KeyVersion string
+	Algorithm  string
+	BatchNum   int32
+	BatchSize  int32
+	Valid      bool
+	Error      string `json:",omitempty"`
+}
+
+// VerifyExport verifies the signatures of the export.sig data against the export.bin data.
+// A signature is valid if it is verified by any of the public keys.
+func VerifyExport(bin, sig []byte, keys []*ecdsa.PublicKey) ([]SignatureCheck, error) {
+	list := &export.TEKSignatureList{}
+	if err := proto.Unmarshal(sig, list); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal the %s: %s", ExportSigFilename, err)
+	}
+	if len(list.Signatures) == 0 {
+		return nil, fmt.Errorf("%s has no signatures", ExportSigFilename)
+	}
+
+	digest := sha256.Sum256(bin)
+	checks := make([]SignatureCheck, 0, len(list.Signatures))
+
+	for _, s := range list.Signatures {
+		check := SignatureCheck{
+			KeyID:      s.GetSignatureInfo().GetVerificationKeyId(),
+			KeyVersion: s.GetSignatureInfo().GetVerificationKeyVersion(),
+			Algorithm:  s.GetSignatureInfo().GetSignatureAlgorithm(),
+			BatchNum:   s.GetBatchNum(),
+			BatchSize:  s.GetBatchSize(),
+		}
+
+		if check.Algorithm != ECDSAWithSHA256 {
+			check.Error = fmt.Sprintf("unsupported signature algorithm [%s]", check.Algorithm)
+		} else {
+			for _, key := range keys {
+				if ecdsa.VerifyASN1(key, digest[:], s.Signature) {
+					check.Valid = true
+					break
+				}
+			}
+			if !check.Valid {
+				check.Error = "signature not verified by any of the public keys"
+			}
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
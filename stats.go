@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"gaen/export"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// ExportStats summarizes the keys of one or more exports
+type ExportStats struct {
+	Exports     int
+	Keys        int
+	RevisedKeys int
+	// Start and End are the time window of the exports, based on the arrival of the keys to the server
+	Start time.Time
+	End   time.Time
+	// KeysPerDay counts the keys per UTC day of their rolling start interval number
+	KeysPerDay             map[string]int
+	RollingPeriods         map[string]int
+	ReportTypes            map[string]int
+	TransmissionRiskLevels map[string]int
+	DaysSinceOnset         map[string]int
+	Batches                []BatchInfo
+	// Padding is the detection of the padding keys, if requested
+	Padding *PaddingReport `json:",omitempty"`
+}
+
+// BatchInfo describes a single export file
+type BatchInfo struct {
+	App       string `json:",omitempty"`
+	Export    string `json:",omitempty"`
+	Path      string
+	Region    string
+	BatchNum  int32
+	BatchSize int32
+	Start     time.Time
+	End       time.Time
+	Keys      int
+}
+
+// unset is the value counted when an optional field of a key is not set
+const unset = "unset"
+
+// NewExportStats returns empty ExportStats
+func NewExportStats() *ExportStats {
+	return &ExportStats{
+		KeysPerDay:             make(map[string]int),
+		RollingPeriods:         make(map[string]int),
+		ReportTypes:            make(map[string]int),
+		TransmissionRiskLevels: make(map[string]int),
+		DaysSinceOnset:         make(map[string]int),
+		Batches:                make([]BatchInfo, 0),
+	}
+}
+
+// ComputeStats returns the ExportStats of the export files
+func ComputeStats(files []ExportFile) (*ExportStats, error) {
+	stats := NewExportStats()
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+		stats.Add(file, exp)
+	}
+	return stats, nil
+}
+
+// Add adds the keys of the export to the stats
+func (s *ExportStats) Add(file ExportFile, exp *export.TemporaryExposureKeyExport) {
+	start := time.Unix(int64(exp.GetStartTimestamp()), 0).UTC()
+	end := time.Unix(int64(exp.GetEndTimestamp()), 0).UTC()
+
+	if s.Exports == 0 || start.Before(s.Start) {
+		s.Start = start
+	}
+	if s.Exports == 0 || end.After(s.End) {
+		s.End = end
+	}
+	s.Exports++
+
+	s.Batches = append(s.Batches, BatchInfo{
+		App:       file.App,
+		Export:    file.Export,
+		Path:      file.Path,
+		Region:    exp.GetRegion(),
+		BatchNum:  exp.GetBatchNum(),
+		BatchSize: exp.GetBatchSize(),
+		Start:     start,
+		End:       end,
+		Keys:      len(exp.Keys),
+	})
+
+	s.Keys += len(exp.Keys)
+	s.RevisedKeys += len(exp.RevisedKeys)
+
+	for _, key := range exp.Keys {
+		day := unset
+		if key.RollingStartIntervalNumber != nil {
+			day = JSONTime(IntervalTime(int(key.GetRollingStartIntervalNumber()))).String()
+		}
+		s.KeysPerDay[day]++
+
+		s.RollingPeriods[optionalInt(key.RollingPeriod)]++
+		s.TransmissionRiskLevels[optionalInt(key.TransmissionRiskLevel)]++
+		s.DaysSinceOnset[optionalInt(key.DaysSinceOnsetOfSymptoms)]++
+
+		reportType := unset
+		if key.ReportType != nil {
+			reportType = key.GetReportType().String()
+		}
+		s.ReportTypes[reportType]++
+	}
+}
+
+// WriteTable writes the stats as a set of tables
+func (s *ExportStats) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Exports\t%d\n", s.Exports)
+	fmt.Fprintf(tw, "Keys\t%d\n", s.Keys)
+	fmt.Fprintf(tw, "Revised keys\t%d\n", s.RevisedKeys)
+	fmt.Fprintf(tw, "Window\t%s - %s\n", s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339))
+
+	sections := []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"KEYS PER DAY", s.KeysPerDay},
+		{"ROLLING PERIOD", s.RollingPeriods},
+		{"REPORT TYPE", s.ReportTypes},
+		{"TRANSMISSION RISK LEVEL", s.TransmissionRiskLevels},
+		{"DAYS SINCE ONSET", s.DaysSinceOnset},
+	}
+	for _, section := range sections {
+		fmt.Fprintf(tw, "\n%s\tKEYS\n", section.title)
+		for _, k := range sortedKeys(section.counts) {
+			fmt.Fprintf(tw, "%s\t%d\n", k, section.counts[k])
+		}
+	}
+
+	fmt.Fprintln(tw, "\nEXPORT\tREGION\tBATCH\tSTART\tEND\tKEYS")
+	for _, b := range s.Batches {
+		name := b.Path
+		if b.App != "" {
+			name = b.App + "/" + b.Export
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%s\t%s\t%d\n",
+			name, b.Region, b.BatchNum, b.BatchSize,
+			b.Start.Format(time.RFC3339), b.End.Format(time.RFC3339), b.Keys)
+	}
+
+	if s.Padding != nil {
+		p := s.Padding
+		fmt.Fprintf(tw, "\nLikely padding keys\t%d of %d (confidence %.2f)\n", p.LikelyPadding, p.Keys, p.Confidence)
+		fmt.Fprintf(tw, "Estimated real keys\t%d\n", p.EstimatedRealKeys)
+		if len(p.Findings) > 0 {
+			fmt.Fprintln(tw, "\nHEURISTIC\tEXPORT\tKEYS\tSCORE\tDETAIL")
+			for _, f := range p.Findings {
+				fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%s\n", f.Heuristic, f.Export, f.Keys, f.Score, f.Detail)
+			}
+		}
+	}
+
+	return tw.Flush()
+}
+
+// optionalInt returns the string value of an optional int field, or unset
+func optionalInt(v *int32) string {
+	if v == nil {
+		return unset
+	}
+	return strconv.Itoa(int(*v))
+}
+
+// sortedKeys returns the keys of the counts, sorted numerically when possible and with unset last
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == unset || keys[j] == unset {
+			return keys[j] == unset && keys[i] != unset
+		}
+		return lessExport(keys[i], keys[j])
+	})
+	return keys
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ExposureKeyHMAC returns the HMAC-SHA256 of the keys bound to a verification certificate.
+// Every key is written as key.rollingStartNumber.rollingPeriod.transmissionRisk, with the base64 key,
+// and the keys, sorted by base64 key, are joined with commas. There must be at least a key.
+func ExposureKeyHMAC(keys []PublishKey, secret []byte) ([]byte, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys to compute the HMAC of")
+	}
+
+	sorted := make([]PublishKey, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	perKey := make([]string, 0, len(sorted))
+	for _, key := range sorted {
+		perKey = append(perKey, fmt.Sprintf("%s.%d.%d.%d", key.Key, key.RollingStartNumber, key.RollingPeriod, key.TransmissionRisk))
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(perKey, ",")))
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestExposureKeyHMAC(t *testing.T) {
+	// the expected HMACs are computed with the algorithm of the exposure-notifications-verification-server
+	secret := []byte("1234567890123456")
+	k1 := PublishKey{Key: "+wK7aDl5cTC2wbZ4Ux6bvw==", RollingStartNumber: 2668896, RollingPeriod: 144}
+	k2 := PublishKey{Key: "9HrS/zxEcf4MbeEcjnNwnQ==", RollingStartNumber: 2669616, RollingPeriod: 144}
+	k3 := PublishKey{Key: "Bk0L0qN2BBfg1Fwjjt0xnw==", RollingStartNumber: 2669472, RollingPeriod: 72, TransmissionRisk: 5}
+	risky := k1
+	risky.TransmissionRisk = 3
+
+	tests := []struct {
+		name string
+		keys []PublishKey
+		want string
+	}{
+		{"single key", []PublishKey{k1}, "gS6L+OWL2LY641R5q0pItc9IC0o9TwL0vxnlB965beU="},
+		{"two keys", []PublishKey{k1, k2}, "KUjfTde78WwaTrGhyTIivyeb7h4X9C+Zmz5bQspnD8s="},
+		{"unsorted keys", []PublishKey{k3, k2, k1}, "DB3hL0PWAtSRxfeDvDKptgWz/5IFU/PpmsXZN1T6TWA="},
+		{"sorted keys", []PublishKey{k1, k2, k3}, "DB3hL0PWAtSRxfeDvDKptgWz/5IFU/PpmsXZN1T6TWA="},
+		{"transmission risk", []PublishKey{risky}, "nsSPg03qcA6Csirmsxa9MQ5B3ykqixhH1ocSUwD6dTI="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mac, err := ExposureKeyHMAC(tt.keys, secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := base64.StdEncoding.EncodeToString(mac); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ExposureKeyHMAC(nil, secret); err == nil {
+		t.Error("no error without keys")
+	}
+}
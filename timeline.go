@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Timeline aggregates the unique keys of a set of exports per day, for every app
+type Timeline struct {
+	UniqueKeys    int
+	DuplicateKeys int
+	Days          []TimelineDay
+}
+
+// TimelineDay is a day of the Timeline of an app
+type TimelineDay struct {
+	App string
+	Day string
+	// UploadedKeys are the unique keys first published in an export of the day
+	UploadedKeys int
+	// KeysByKeyDate are the unique keys whose rolling start interval number is in the day
+	KeysByKeyDate int
+	// EstimatedUsers is the estimate of the users that uploaded their keys in the day.
+	// A user uploads at most one key for every key date, so it is the maximum number of keys
+	// uploaded in the day with the same key date.
+	EstimatedUsers int
+}
+
+// timelineKey is a key seen in the exports, with the day it was first published
+type timelineKey struct {
+	app       string
+	uploadDay string
+	keyDay    string
+}
+
+// ComputeTimeline returns the Timeline of the export files. The keys published in more than
+// one export of the same app (e.g. overlapping day buckets) are counted once, on the first upload day.
+func ComputeTimeline(files []ExportFile) (*Timeline, error) {
+	timeline := &Timeline{Days: make([]TimelineDay, 0)}
+	keys := make(map[string]*timelineKey)
+
+	for _, file := range files {
+		exp, err := UnmarshalExportFile(file.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file.Path, err)
+		}
+
+		uploadDay := unset
+		if exp.StartTimestamp != nil {
+			uploadDay = time.Unix(int64(exp.GetStartTimestamp()), 0).UTC().Format("2006-01-02")
+		}
+
+		for _, key := range exp.Keys {
+			id := file.App + "/" + string(key.KeyData)
+			if seen, ok := keys[id]; ok {
+				timeline.DuplicateKeys++
+				if uploadDay < seen.uploadDay {
+					seen.uploadDay = uploadDay
+				}
+				continue
+			}
+
+			keyDay := unset
+			if key.RollingStartIntervalNumber != nil {
+				keyDay = JSONTime(IntervalTime(int(key.GetRollingStartIntervalNumber()))).String()
+			}
+			keys[id] = &timelineKey{app: file.App, uploadDay: uploadDay, keyDay: keyDay}
+		}
+	}
+	timeline.UniqueKeys = len(keys)
+
+	type appDay struct{ app, day string }
+	days := make(map[appDay]*TimelineDay)
+	perKeyDay := make(map[appDay]map[string]int)
+
+	day := func(app, d string) *TimelineDay {
+		k := appDay{app, d}
+		if _, ok := days[k]; !ok {
+			days[k] = &TimelineDay{App: app, Day: d}
+			perKeyDay[k] = make(map[string]int)
+		}
+		return days[k]
+	}
+
+	for _, key := range keys {
+		uploaded := day(key.app, key.uploadDay)
+		uploaded.UploadedKeys++
+
+		k := appDay{key.app, key.uploadDay}
+		perKeyDay[k][key.keyDay]++
+		if n := perKeyDay[k][key.keyDay]; n > uploaded.EstimatedUsers {
+			uploaded.EstimatedUsers = n
+		}
+
+		day(key.app, key.keyDay).KeysByKeyDate++
+	}
+
+	for _, d := range days {
+		timeline.Days = append(timeline.Days, *d)
+	}
+	sort.Slice(timeline.Days, func(i, j int) bool {
+		if timeline.Days[i].App != timeline.Days[j].App {
+			return timeline.Days[i].App < timeline.Days[j].App
+		}
+		return timeline.Days[i].Day < timeline.Days[j].Day
+	})
+
+	return timeline, nil
+}
+
+// WriteCSV writes the days of the timeline as CSV, with a header line
+func (t *Timeline) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"app", "day", "uploaded_keys", "keys_by_key_date", "estimated_users"}); err != nil {
+		return err
+	}
+	for _, d := range t.Days {
+		err := cw.Write([]string{
+			d.App,
+			d.Day,
+			strconv.Itoa(d.UploadedKeys),
+			strconv.Itoa(d.KeysByKeyDate),
+			strconv.Itoa(d.EstimatedUsers),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
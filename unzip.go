@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -9,9 +10,40 @@ import (
 	"strings"
 )
 
+// UnzipLimits are the limits enforced while decompressing a zip archive.
+// A zero value disables the corresponding check.
+type UnzipLimits struct {
+	// MaxFiles is the maximum number of entries in the archive
+	MaxFiles int
+	// MaxFileSize is the maximum uncompressed size of a single entry, in bytes
+	MaxFileSize int64
+	// MaxTotalSize is the maximum uncompressed size of all the entries, in bytes
+	MaxTotalSize int64
+	// MaxRatio is the maximum ratio between the uncompressed and compressed size of an entry
+	MaxRatio float64
+	// AllowedFiles is the list of the only entries allowed in the archive
+	AllowedFiles []string
+}
+
+// DefaultUnzipLimits are the limits used for the export zips. An export zip contains only
+// the export.bin and export.sig files, and even the biggest exports are a few megabytes.
+var DefaultUnzipLimits = UnzipLimits{
+	MaxFiles:     2,
+	MaxFileSize:  64 << 20,
+	MaxTotalSize: 128 << 20,
+	MaxRatio:     100,
+	AllowedFiles: []string{ExportBinFilename, ExportSigFilename},
+}
+
 // Unzip will decompress a zip archive, moving all files and folders
 // within the zip file (parameter 1) to an output directory (parameter 2).
+// The DefaultUnzipLimits are enforced.
 func Unzip(src string, dest string) ([]string, error) {
+	return UnzipWithLimits(src, dest, DefaultUnzipLimits)
+}
+
+// UnzipWithLimits is like Unzip, but it enforces the specified limits
+func UnzipWithLimits(src string, dest string, limits UnzipLimits) ([]string, error) {
 	var filenames []string
 
 	r, err := zip.OpenReader(src)
@@ -20,47 +52,186 @@ func Unzip(src string, dest string) ([]string, error) {
 	}
 	defer r.Close()
 
-	for _, f := range r.File {
-
+	err = forEachZipEntry(&r.Reader, limits, func(f *zip.File, rc io.Reader) (int64, error) {
 		// Store filename/path for returning and using later on
 		fpath := filepath.Join(dest, f.Name)
 		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return filenames, fmt.Errorf("%s: illegal file path", fpath)
+			return 0, fmt.Errorf("%s: illegal file path", fpath)
 		}
 
 		filenames = append(filenames, fpath)
 
-		if f.FileInfo().IsDir() {
+		if rc == nil {
 			// Make Folder
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
+			return 0, os.MkdirAll(fpath, os.ModePerm)
 		}
 
 		// Make File
-		if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return filenames, err
+		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return 0, err
 		}
 
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 		if err != nil {
-			return filenames, err
+			return 0, err
+		}
+		defer outFile.Close()
+
+		return io.Copy(outFile, rc)
+	})
+	return filenames, err
+}
+
+// forEachZipEntry checks the entries of the zip against the limits, calling fn for every entry with
+// the reader of its content, nil for the folders. The declared sizes cannot be trusted, so the reader
+// is bounded as well: fn returns the number of bytes read, checked against the limits.
+func forEachZipEntry(r *zip.Reader, limits UnzipLimits, fn func(f *zip.File, rc io.Reader) (int64, error)) error {
+	if limits.MaxFiles > 0 && len(r.File) > limits.MaxFiles {
+		return fmt.Errorf("zip has %d entries, more than the limit of %d", len(r.File), limits.MaxFiles)
+	}
+
+	var totalSize int64
+	seen := make(map[string]bool)
+
+	for _, f := range r.File {
+		if !limits.allowed(f.Name) {
+			return fmt.Errorf("%s: unexpected entry in zip", f.Name)
+		}
+		// a duplicate entry would silently overwrite the previous one
+		if seen[f.Name] {
+			return fmt.Errorf("%s: duplicate entry in zip", f.Name)
+		}
+		seen[f.Name] = true
+
+		if f.FileInfo().IsDir() {
+			if _, err := fn(f, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Only regular files are read, the mode in the zip is not trusted
+		if !f.Mode().IsRegular() {
+			return fmt.Errorf("%s: not a regular file", f.Name)
+		}
+		if err := limits.checkDeclaredSize(f); err != nil {
+			return err
 		}
 
 		rc, err := f.Open()
 		if err != nil {
-			return filenames, err
+			return err
 		}
 
-		_, err = io.Copy(outFile, rc)
-
-		// Close the file without defer to close before next iteration of loop
-		outFile.Close()
+		maxSize := limits.maxEntrySize(f, totalSize)
+		var in io.Reader = rc
+		if maxSize >= 0 {
+			in = io.LimitReader(rc, maxSize+1)
+		}
+		written, err := fn(f, in)
 		rc.Close()
 
+		if err == nil && maxSize >= 0 && written > maxSize {
+			err = fmt.Errorf("%s: uncompressed size exceeds the limits", f.Name)
+		}
 		if err != nil {
-			return filenames, err
+			return err
 		}
+		totalSize += written
 	}
+	return nil
+}
 
-	return filenames, nil
+// IsZip returns true if the data starts with the signature of a zip file
+func IsZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// ReadExportZip reads the export.bin and export.sig files from the data of an export zip, enforcing the limits.
+// The export.sig is nil if it is not in the zip.
+func ReadExportZip(data []byte, limits UnzipLimits) ([]byte, []byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make(map[string][]byte)
+	err = forEachZipEntry(r, limits, func(f *zip.File, rc io.Reader) (int64, error) {
+		if rc == nil {
+			return 0, fmt.Errorf("%s: not a regular file", f.Name)
+		}
+		var buf bytes.Buffer
+		n, err := io.Copy(&buf, rc)
+		entries[f.Name] = buf.Bytes()
+		return n, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bin, ok := entries[ExportBinFilename]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s not found in zip", ExportBinFilename)
+	}
+	return bin, entries[ExportSigFilename], nil
+}
+
+// zipOverhead is the room left for the headers of the entries in the size of a zip
+const zipOverhead = 64 << 10
+
+// MaxZipSize returns the maximum size of a zip whose entries can fit the MaxTotalSize, or -1 if there is no limit
+func (l UnzipLimits) MaxZipSize() int64 {
+	if l.MaxTotalSize <= 0 {
+		return -1
+	}
+	return l.MaxTotalSize + zipOverhead
+}
+
+// allowed returns true if the entry is in the allow-list, or if there is no allow-list
+func (l UnzipLimits) allowed(name string) bool {
+	if len(l.AllowedFiles) == 0 {
+		return true
+	}
+	for _, allowed := range l.AllowedFiles {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDeclaredSize checks the sizes declared in the zip header of the entry
+func (l UnzipLimits) checkDeclaredSize(f *zip.File) error {
+	size := int64(f.UncompressedSize64)
+	if l.MaxFileSize > 0 && size > l.MaxFileSize {
+		return fmt.Errorf("%s: uncompressed size %d exceeds the limit of %d bytes", f.Name, size, l.MaxFileSize)
+	}
+	if l.MaxRatio > 0 && f.CompressedSize64 > 0 {
+		ratio := float64(f.UncompressedSize64) / float64(f.CompressedSize64)
+		if ratio > l.MaxRatio {
+			return fmt.Errorf("%s: compression ratio %.1f exceeds the limit of %.1f", f.Name, ratio, l.MaxRatio)
+		}
+	}
+	return nil
+}
+
+// maxEntrySize returns the maximum number of bytes that can be extracted from the entry,
+// given the bytes already extracted. It returns -1 if there is no limit.
+func (l UnzipLimits) maxEntrySize(f *zip.File, totalSize int64) int64 {
+	max := int64(-1)
+	if l.MaxFileSize > 0 {
+		max = l.MaxFileSize
+	}
+	if l.MaxTotalSize > 0 {
+		if left := l.MaxTotalSize - totalSize; max < 0 || left < max {
+			max = left
+		}
+	}
+	if l.MaxRatio > 0 {
+		byRatio := int64(float64(f.CompressedSize64) * l.MaxRatio)
+		if max < 0 || byRatio < max {
+			max = byRatio
+		}
+	}
+	return max
 }
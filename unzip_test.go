@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// zipEntry is an entry of a zip written by writeTestZip
+type zipEntry struct {
+	name string
+	data []byte
+}
+
+// writeTestZip writes a zip with the entries, in order, into the dir
+func writeTestZip(t *testing.T, dir string, entries ...zipEntry) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		f, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(entry.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(dir, "test.zip")
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestUnzipWithLimits(t *testing.T) {
+	bin := zipEntry{ExportBinFilename, []byte(ExportHeader + "keys")}
+	sig := zipEntry{ExportSigFilename, []byte("signature")}
+
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		limits  UnzipLimits
+		err     string
+	}{
+		{"valid", []zipEntry{bin, sig}, DefaultUnzipLimits, ""},
+		{"duplicate entry", []zipEntry{bin, bin}, DefaultUnzipLimits, "duplicate entry"},
+		{"disallowed entry", []zipEntry{bin, {"evil.sh", []byte("rm -rf /")}}, DefaultUnzipLimits, "unexpected entry"},
+		{"too many entries", []zipEntry{bin, sig, {"extra", nil}}, DefaultUnzipLimits, "more than the limit of 2"},
+		{"over the file size", []zipEntry{{ExportBinFilename, make([]byte, 1024)}}, UnzipLimits{MaxFileSize: 1000}, "exceeds the limit"},
+		{"over the total size", []zipEntry{{ExportBinFilename, make([]byte, 600)}, {ExportSigFilename, make([]byte, 600)}}, UnzipLimits{MaxTotalSize: 1000}, "exceeds the limits"},
+		{"over the ratio", []zipEntry{{ExportBinFilename, make([]byte, 1<<20)}}, UnzipLimits{MaxRatio: 100}, "compression ratio"},
+		{"illegal path", []zipEntry{{"../escape", []byte("x")}}, UnzipLimits{}, "illegal file path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gaen-unzip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			src := writeTestZip(t, dir, tt.entries...)
+			dest := filepath.Join(dir, "out")
+			_, err = UnzipWithLimits(src, dest, tt.limits)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "escape")); err == nil {
+				t.Fatal("entry extracted outside of the destination")
+			}
+		})
+	}
+}
+
+func TestDownloadZipLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 2048))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "gaen-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	zipPath := filepath.Join(dir, "export.zip")
+	if err := DownloadZip(server.URL, zipPath, 1024); err == nil {
+		t.Fatal("no error downloading a zip over the limit")
+	}
+	if err := DownloadZip(server.URL, zipPath, 2048); err != nil {
+		t.Fatal(err)
+	}
+}
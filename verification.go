@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultVerificationURL is the default base url of the device API of a local exposure-notifications-verification-server
+const DefaultVerificationURL = "http://localhost:8080"
+
+// VerifyCodeRequest is the body of the /api/verify request
+type VerifyCodeRequest struct {
+	Code    string   `json:"code"`
+	Accept  []string `json:"accept,omitempty"`
+	Padding string   `json:"padding"`
+}
+
+// VerifyCodeResponse is the response of the /api/verify request
+type VerifyCodeResponse struct {
+	TestType    string `json:"testtype"`
+	SymptomDate string `json:"symptomDate,omitempty"`
+	TestDate    string `json:"testDate,omitempty"`
+	Token       string `json:"token"`
+	Error       string `json:"error,omitempty"`
+	ErrorCode   string `json:"errorCode,omitempty"`
+}
+
+// CertificateRequest is the body of the /api/certificate request
+type CertificateRequest struct {
+	Token           string `json:"token"`
+	ExposureKeyHMAC string `json:"ekeyhmac"`
+	Padding         string `json:"padding"`
+}
+
+// CertificateResponse is the response of the /api/certificate request
+type CertificateResponse struct {
+	Certificate string `json:"certificate"`
+	Error       string `json:"error,omitempty"`
+	ErrorCode   string `json:"errorCode,omitempty"`
+}
+
+// VerificationClient is a client of the device API of an exposure-notifications-verification-server
+type VerificationClient struct {
+	// URL is the base url of the server
+	URL string
+	// APIKey is the device API key of the app
+	APIKey string
+}
+
+// VerifyCode exchanges the verification code for a verification token
+func (c *VerificationClient) VerifyCode(code string) (*VerifyCodeResponse, error) {
+	var resp VerifyCodeResponse
+	req := &VerifyCodeRequest{Code: code}
+	if err := padRequest(req, &req.Padding); err != nil {
+		return nil, err
+	}
+	if err := c.post("/api/verify", req, &resp, &resp.Error, &resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Certificate exchanges the verification token and the HMAC of the keys for a verification certificate
+func (c *VerificationClient) Certificate(token string, ekeyhmac []byte) (*CertificateResponse, error) {
+	var resp CertificateResponse
+	req := &CertificateRequest{
+		Token:           token,
+		ExposureKeyHMAC: base64.StdEncoding.EncodeToString(ekeyhmac),
+	}
+	if err := padRequest(req, &req.Padding); err != nil {
+		return nil, err
+	}
+	if err := c.post("/api/certificate", req, &resp, &resp.Error, &resp.ErrorCode); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// post posts the request to the path, decoding the response. The error and error code
+// of the response are returned as an error.
+func (c *VerificationClient) post(path string, req, resp interface{}, errMsg, errCode *string) error {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(c.URL, "/")+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-API-Key", c.APIKey)
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("error calling %s. Status code %d: %s", path, httpResp.StatusCode, bytes.TrimSpace(body))
+	}
+	if httpResp.StatusCode != http.StatusOK || *errMsg != "" {
+		return fmt.Errorf("error calling %s. Status code %d: %s %s", path, httpResp.StatusCode, *errCode, *errMsg)
+	}
+	return nil
+}
+
+// VerifyAndPublish performs the verification flow: the code is exchanged for a token, and the token and the HMAC
+// of the keys for a certificate, that is used to publish the keys. The symptom onset of the code, if any, is published
+// with the keys. The keys and the options are checked before the code, that can be used only once, is exchanged.
+func VerifyAndPublish(client *VerificationClient, code string, teks []*TemporaryExposureKey, publishURL string, opts PublishOptions) (*VerifyCodeResponse, *PublishResponse, error) {
+	if opts.HealthAuthorityID == "" {
+		return nil, nil, fmt.Errorf("the health authority ID is required")
+	}
+	if len(teks) == 0 {
+		return nil, nil, fmt.Errorf("no keys to publish")
+	}
+
+	if opts.HMACKey == nil {
+		opts.HMACKey = make([]byte, 16)
+		if _, err := rand.Read(opts.HMACKey); err != nil {
+			return nil, nil, err
+		}
+	}
+	ekeyhmac, err := ExposureKeyHMAC(NewPublishKeys(teks, opts.TransmissionRisk), opts.HMACKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	verified, err := client.VerifyCode(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := client.Certificate(verified.Token, ekeyhmac)
+	if err != nil {
+		return verified, nil, err
+	}
+	opts.VerificationPayload = cert.Certificate
+
+	req, err := NewPublishRequest(teks, opts)
+	if err != nil {
+		return verified, nil, err
+	}
+	if verified.SymptomDate != "" {
+		symptomDate, err := time.Parse("2006-01-02", verified.SymptomDate)
+		if err != nil {
+			return verified, nil, fmt.Errorf("invalid symptom date [%s]", verified.SymptomDate)
+		}
+		req.SymptomOnsetInterval = int32(symptomDate.Unix() / 600)
+	}
+
+	published, err := Publish(publishURL, req)
+	return verified, published, err
+}
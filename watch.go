@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gaen/export"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is the default interval between two polls of the backends
+const DefaultWatchInterval = time.Hour
+
+// DefaultNotifyTimeout is the default timeout of the webhook and of the command run on a new export
+const DefaultNotifyTimeout = time.Minute
+
+// WatchedFilename is the name of the file written in an export folder with the actions done on the export
+const WatchedFilename = ".watched"
+
+// WatchOptions are the options of a Watcher
+type WatchOptions struct {
+	// Download are the options used to download the new exports
+	Download DownloadOptions
+	// PublicKeys, if set, are used to verify the signatures of the new exports
+	PublicKeys []*ecdsa.PublicKey
+	// Sink, if set, is where the keys of the new exports are appended as ndjson, with the Rows, Query and IDFormat
+	// of the SinkOptions. The format of the SinkOptions is ignored, since only ndjson can be appended.
+	Sink        io.Writer
+	SinkOptions OutputOptions
+	// RPISets are the sets of RPIs matched against the new exports, by name
+	RPISets map[string][]ID
+	// IDFormat is the format of the IDs of the matches in the WatchEvent
+	IDFormat IDFormat
+	// Webhook, if set, is the url the WatchEvent is posted to when there are new keys or matches
+	Webhook string
+	// Command, if set, is the shell command run with the WatchEvent on its stdin when there are new keys or matches
+	Command string
+	// NotifyTimeout is the timeout of the Webhook and of the Command, DefaultNotifyTimeout if zero
+	NotifyTimeout time.Duration
+}
+
+// WatchEvent is the result of the actions run on a new export
+type WatchEvent struct {
+	App    string `json:"app"`
+	Export string `json:"export"`
+	Path   string `json:"path"`
+	Keys   int    `json:"keys"`
+	// Valid is set only if the signatures were verified
+	Valid      *bool                           `json:"valid,omitempty"`
+	Signatures []SignatureCheck                `json:"signatures,omitempty"`
+	Matches    map[string][]*FormattedRPIMatch `json:"matches,omitempty"`
+	Errors     []string                        `json:"errors,omitempty"`
+}
+
+// watchState is the content of the WatchedFilename, so an action that succeeded is not run again
+// when the others are retried
+type watchState struct {
+	// Decoded is true once the keys were appended to the sink
+	Decoded bool `json:"decoded,omitempty"`
+	// Failed is the permanent failure of the export, like a missing or invalid signature, that is not retried
+	Failed string `json:"failed,omitempty"`
+	// WatchedAt is set once the actions and the notification succeeded, or the export failed and was notified
+	WatchedAt *time.Time `json:"watchedAt,omitempty"`
+}
+
+// readWatchState reads the watchState of the filename, empty if the file does not exist.
+// The files written by the previous versions, with just the time the export was watched, are read as well.
+func readWatchState(filename string) (*watchState, error) {
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &watchState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &watchState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		t, terr := time.Parse(time.RFC3339, strings.TrimSpace(string(b)))
+		if terr != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		state.WatchedAt = &t
+	}
+	return state, nil
+}
+
+// writeWatchState writes the watchState to the filename
+func writeWatchState(filename string, state *watchState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+// Watcher downloads the latest exports of the apps into the workDir, running the actions on the new ones
+type Watcher struct {
+	WorkDir string
+	Apps    []string
+	Options WatchOptions
+}
+
+// Poll downloads the latest export of every app, if not already downloaded, returning the events of the new ones.
+// An error of an app does not stop the others, and all of them are returned.
+func (w *Watcher) Poll() ([]*WatchEvent, []error) {
+	events := make([]*WatchEvent, 0)
+	errs := make([]error, 0)
+
+	for _, app := range w.Apps {
+		event, err := w.poll(app)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", app, err))
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	return events, errs
+}
+
+// poll downloads the latest export of the app, if not already downloaded, and runs the actions on it, returning
+// nil if they already succeeded. The actions done are recorded in the WatchedFilename of the export, and the ones
+// that failed, or the notification, are retried at the next poll. The permanent failures of the export, like a
+// missing or invalid signature or a corrupted export, are notified once and not retried.
+func (w *Watcher) poll(app string) (*WatchEvent, error) {
+	dwln, err := DownloaderFactory(app, w.Options.Download.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	appDir := filepath.Join(w.WorkDir, app)
+	if err := os.MkdirAll(appDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	latest, err := dwln.GetLatestExport()
+	if err != nil {
+		return nil, err
+	}
+
+	// the exports already watched, also by a previous watch, are not new
+	exportDir := filepath.Join(appDir, latest)
+	watched := filepath.Join(exportDir, WatchedFilename)
+	state, err := readWatchState(watched)
+	if err != nil {
+		return nil, err
+	}
+	if state.WatchedAt != nil {
+		return nil, nil
+	}
+
+	// an export downloaded by a poll whose actions failed is not downloaded again
+	file := ExportFile{App: app, Export: latest, Path: filepath.Join(exportDir, ExportBinFilename)}
+	if _, err := os.Stat(file.Path); err != nil {
+		if err := DownloadExport(appDir, app, latest, dwln.GetURL(latest), w.Options.Download); err != nil {
+			return nil, err
+		}
+	}
+
+	event, failed := w.run(file, state)
+	if failed != "" {
+		state.Failed = failed
+	}
+	if err := writeWatchState(watched, state); err != nil {
+		return event, err
+	}
+	if failed == "" && len(event.Errors) > 0 {
+		return event, fmt.Errorf("%s: the actions failed, retrying at the next poll", latest)
+	}
+	if err := w.notify(event); err != nil {
+		return event, fmt.Errorf("%s: %s, retrying at the next poll", latest, err)
+	}
+
+	now := time.Now().UTC()
+	state.WatchedAt = &now
+	if err := writeWatchState(watched, state); err != nil {
+		return event, err
+	}
+	if failed != "" {
+		return event, fmt.Errorf("%s: %s, not retried", latest, failed)
+	}
+	return event, nil
+}
+
+// run runs the actions on the export file not done yet, recording in the state the ones that succeeded.
+// The errors of the actions are collected in the event. If the export failed permanently, the failure
+// is returned and the other actions are not run.
+func (w *Watcher) run(file ExportFile, state *watchState) (*WatchEvent, string) {
+	event := &WatchEvent{App: file.App, Export: file.Export, Path: file.Path}
+	fail := func(msg string) (*WatchEvent, string) {
+		event.Errors = append(event.Errors, msg)
+		return event, msg
+	}
+
+	// the export.sig is read only to verify the signatures
+	var bin, sig []byte
+	var err error
+	if len(w.Options.PublicKeys) > 0 {
+		bin, sig, err = ReadExportFile(file.Path)
+	} else {
+		bin, err = ReadExportBin(file.Path)
+	}
+	// a file that cannot be read is retried, while a file read but not valid is a corrupted export
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		event.Errors = append(event.Errors, err.Error())
+		return event, ""
+	}
+	if err != nil {
+		return fail(err.Error())
+	}
+	exp, err := UnmarshalExport(bin)
+	if err != nil {
+		return fail(err.Error())
+	}
+	event.Keys = len(exp.Keys)
+
+	if len(w.Options.PublicKeys) > 0 {
+		valid := false
+		event.Valid = &valid
+		if sig == nil {
+			return fail(fmt.Sprintf("verify: %s not found", ExportSigFilename))
+		}
+		if event.Signatures, err = VerifyExport(bin, sig, w.Options.PublicKeys); err != nil {
+			return fail(fmt.Sprintf("verify: %s", err))
+		}
+		valid = true
+		for _, c := range event.Signatures {
+			valid = valid && c.Valid
+		}
+		if !valid {
+			return fail("verify: invalid signatures")
+		}
+	}
+
+	// the keys are appended to the sink only once, also when the other actions are retried
+	if w.Options.Sink != nil && !state.Decoded {
+		if err := w.decode(exp); err != nil {
+			event.Errors = append(event.Errors, fmt.Sprintf("decode: %s", err))
+		} else {
+			state.Decoded = true
+		}
+	}
+
+	if len(w.Options.RPISets) > 0 {
+		// the RPIs of the export are derived once for all the sets
+		scanners := make(map[string]*rpiScanner, len(w.Options.RPISets))
+		for name, rpis := range w.Options.RPISets {
+			scanners[name] = newRPIScanner(rpis)
+		}
+		err := forEachExportRPI(file, exp, Window{}, func(file ExportFile, exp *export.TemporaryExposureKeyExport, tek *TemporaryExposureKey, rpi *RollingProximityIdentifier) error {
+			for _, scanner := range scanners {
+				if err := scanner.add(file, exp, tek, rpi); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			event.Errors = append(event.Errors, fmt.Sprintf("match: %s", err))
+		}
+
+		for name, scanner := range scanners {
+			if err != nil || len(scanner.matches) == 0 {
+				continue
+			}
+			if event.Matches == nil {
+				event.Matches = make(map[string][]*FormattedRPIMatch)
+			}
+			event.Matches[name] = FormatMatches(scanner.matches, w.Options.IDFormat)
+		}
+	}
+
+	return event, ""
+}
+
+// decode appends the keys of the export to the sink, as ndjson
+func (w *Watcher) decode(exp *export.TemporaryExposureKeyExport) error {
+	opts := w.Options.SinkOptions
+	opts.Format = OutputNDJSON
+	kw, err := NewKeyWriter(w.Options.Sink, opts)
+	if err != nil {
+		return err
+	}
+
+	rpis := RPIsNone
+	if opts.Rows == RowsRPI {
+		rpis = RPIsEager
+	}
+	it, err := NewKeyIterator(exp, rpis)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		if err := kw.Write(it.Key()); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return kw.Close()
+}
+
+// notify posts the event to the webhook and runs the command, if there are new keys, matches or errors
+func (w *Watcher) notify(event *WatchEvent) error {
+	if event.Keys == 0 && len(event.Matches) == 0 && len(event.Errors) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	timeout := w.Options.NotifyTimeout
+	if timeout <= 0 {
+		timeout = DefaultNotifyTimeout
+	}
+
+	if w.Options.Webhook != "" {
+		client := &http.Client{Timeout: timeout}
+		resp, err := client.Post(w.Options.Webhook, "application/json", bytes.NewReader(b))
+		if err != nil {
+			return fmt.Errorf("webhook: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook: status code %d", resp.StatusCode)
+		}
+	}
+
+	if w.Options.Command != "" {
+		matches := 0
+		for _, m := range event.Matches {
+			matches += len(m)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", w.Options.Command)
+		cmd.Stdin = bytes.NewReader(b)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(),
+			"GAEN_APP="+event.App,
+			"GAEN_EXPORT="+event.Export,
+			"GAEN_PATH="+event.Path,
+			"GAEN_KEYS="+strconv.Itoa(event.Keys),
+			"GAEN_MATCHES="+strconv.Itoa(matches),
+		)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("command: %s", err)
+		}
+	}
+	return nil
+}
+
+// Run polls the backends every interval, until stop is closed. The events and the errors are passed to the
+// callbacks, that can be nil.
+func (w *Watcher) Run(interval time.Duration, stop <-chan struct{}, onEvent func(*WatchEvent), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		events, errs := w.Poll()
+		for _, event := range events {
+			if onEvent != nil {
+				onEvent(event)
+			}
+		}
+		for _, err := range errs {
+			if onError != nil {
+				onError(err)
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReadRPISet reads the RPIs of the file, one per line in the format
+func ReadRPISet(filename string, format IDFormat) ([]ID, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rpis := make([]ID, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		rpi, err := format.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		rpis = append(rpis, rpi)
+	}
+	return rpis, scanner.Err()
+}